@@ -3,6 +3,9 @@ package ast
 import (
 	"bytes"
 	"clint/token"
+	"fmt"
+	"math/big"
+	"path/filepath"
 	"strings"
 )
 
@@ -10,6 +13,8 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position just past the last character of the node
 }
 
 // Statement ...
@@ -34,6 +39,13 @@ func (expStmt *ExpressionStatement) statementNode() {}
 
 // TokenLiteral ...
 func (expStmt *ExpressionStatement) TokenLiteral() string { return expStmt.Token.Literal }
+func (expStmt *ExpressionStatement) Pos() token.Position  { return expStmt.Token.Pos }
+func (expStmt *ExpressionStatement) End() token.Position {
+	if expStmt.Expression != nil {
+		return expStmt.Expression.End()
+	}
+	return expStmt.Token.End
+}
 func (expStmt *ExpressionStatement) String() string {
 	if expStmt.Expression != nil {
 		return expStmt.Expression.String()
@@ -49,10 +61,15 @@ type PrefixExpression struct {
 
 func (prefix *PrefixExpression) expressionNode()      {}
 func (prefix *PrefixExpression) TokenLiteral() string { return prefix.Token.Literal }
+func (prefix *PrefixExpression) Pos() token.Position  { return prefix.Token.Pos }
+func (prefix *PrefixExpression) End() token.Position  { return prefix.RightHand.End() }
 func (prefix *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(prefix.Operator)
+	if prefix.Token.Type == token.NOT {
+		out.WriteString(" ")
+	}
 	out.WriteString(prefix.RightHand.String())
 	out.WriteString(")")
 
@@ -68,6 +85,8 @@ type InfixExpression struct {
 
 func (infix *InfixExpression) expressionNode()      {}
 func (infix *InfixExpression) TokenLiteral() string { return infix.Token.Literal }
+func (infix *InfixExpression) Pos() token.Position  { return infix.LeftHand.Pos() }
+func (infix *InfixExpression) End() token.Position  { return infix.RightHand.End() }
 func (infix *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -79,6 +98,51 @@ func (infix *InfixExpression) String() string {
 	return out.String()
 }
 
+// LogicalExpression is a short-circuiting `and` or `or`. It is kept apart
+// from InfixExpression because its right hand side is evaluated lazily.
+type LogicalExpression struct {
+	Token     token.Token // token.AND or token.OR
+	LeftHand  Expression
+	Operator  string
+	RightHand Expression
+}
+
+func (le *LogicalExpression) expressionNode()      {}
+func (le *LogicalExpression) TokenLiteral() string { return le.Token.Literal }
+func (le *LogicalExpression) Pos() token.Position  { return le.LeftHand.Pos() }
+func (le *LogicalExpression) End() token.Position  { return le.RightHand.End() }
+func (le *LogicalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(le.LeftHand.String())
+	out.WriteString(" " + le.Operator + " ")
+	out.WriteString(le.RightHand.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// AssignExpression rebinds an existing var binding, or stores into an
+// element of an array or hash when Target is an IndexExpression.
+type AssignExpression struct {
+	Token  token.Token // token.ASSIGN
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position  { return ae.Target.Pos() }
+func (ae *AssignExpression) End() token.Position  { return ae.Value.End() }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -88,6 +152,13 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	return ie.Consequence.End()
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("if")
@@ -103,13 +174,16 @@ func (ie *IfExpression) String() string {
 }
 
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // token.LPAREN
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Token
 }
 
 func (callExp *CallExpression) expressionNode()      {}
 func (callExp *CallExpression) TokenLiteral() string { return callExp.Token.Literal }
+func (callExp *CallExpression) Pos() token.Position  { return callExp.Function.Pos() }
+func (callExp *CallExpression) End() token.Position  { return callExp.Rparen.End }
 func (callExp *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -126,13 +200,245 @@ func (callExp *CallExpression) String() string {
 	return out.String()
 }
 
+// ArrayLiteral ...
+type ArrayLiteral struct {
+	Token    token.Token // token.LBRACKET
+	Elements []Expression
+	Rbracket token.Token
+}
+
+func (al *ArrayLiteral) expressionNode()      {}
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position  { return al.Rbracket.End }
+func (al *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// ModuleStatement declares a module: module Geometry { ... }. The
+// bindings made at the top level of Body are its members.
+type ModuleStatement struct {
+	Doc   *CommentGroup // comments directly above the statement, or nil
+	Token token.Token   // token.MODULE
+	Name  *Identifier
+	Body  *BlockStatement
+}
+
+func (ms *ModuleStatement) statementNode()       {}
+func (ms *ModuleStatement) TokenLiteral() string { return ms.Token.Literal }
+func (ms *ModuleStatement) Pos() token.Position  { return ms.Token.Pos }
+func (ms *ModuleStatement) End() token.Position  { return ms.Body.End() }
+func (ms *ModuleStatement) String() string {
+	return ms.TokenLiteral() + " " + ms.Name.String() + " {" + ms.Body.String() + "}"
+}
+
+// ImportStatement loads the module in another source file:
+// import "geometry" or import Geometry from "./geo.clint".
+type ImportStatement struct {
+	Token token.Token // token.IMPORT
+	Alias *Identifier // the name after import, or nil
+	Path  *StringLiteral
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) Pos() token.Position  { return is.Token.Pos }
+func (is *ImportStatement) End() token.Position  { return is.Path.End() }
+func (is *ImportStatement) String() string {
+	if is.Alias != nil {
+		return is.TokenLiteral() + " " + is.Alias.String() + " from " + is.Path.String()
+	}
+	return is.TokenLiteral() + " " + is.Path.String()
+}
+
+// Name returns the name the module is bound to: the alias if there is
+// one, otherwise the base name of the path without its extension.
+func (is *ImportStatement) Name() string {
+	if is.Alias != nil {
+		return is.Alias.Value
+	}
+	base := filepath.Base(is.Path.Value)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// ClassStatement declares a class:
+// class Point { fun init(x, y) { ... } fun norm() { ... } }, or
+// class Circle < Shape { ... } for a subclass.
+type ClassStatement struct {
+	Doc        *CommentGroup // comments directly above the statement, or nil
+	Token      token.Token   // token.CLASS
+	Name       *Identifier
+	Superclass Expression // nil if the class does not inherit
+	Methods    []*Method
+	Rbrace     token.Token
+}
+
+func (cs *ClassStatement) statementNode()       {}
+func (cs *ClassStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ClassStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ClassStatement) End() token.Position  { return cs.Rbrace.End }
+func (cs *ClassStatement) String() string {
+	methods := []string{}
+	for _, m := range cs.Methods {
+		methods = append(methods, m.String())
+	}
+
+	superclass := ""
+	if cs.Superclass != nil {
+		superclass = " < " + cs.Superclass.String()
+	}
+
+	return cs.TokenLiteral() + " " + cs.Name.String() + superclass + " {" + strings.Join(methods, " ") + "}"
+}
+
+// Method is a method declared in a class body: fun norm() { ... }.
+type Method struct {
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (m *Method) String() string {
+	params := []string{}
+	for _, p := range m.Function.Parameters {
+		params = append(params, p.String())
+	}
+
+	return m.Function.TokenLiteral() + " " + m.Name.String() + "(" + strings.Join(params, ", ") + ") " + m.Function.Body.String()
+}
+
+// SuperExpression looks up a method of the superclass of the class the
+// enclosing method is declared in: super.area.
+type SuperExpression struct {
+	Token  token.Token // token.SUPER
+	Method *Identifier
+}
+
+func (se *SuperExpression) expressionNode()      {}
+func (se *SuperExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SuperExpression) Pos() token.Position  { return se.Token.Pos }
+func (se *SuperExpression) End() token.Position  { return se.Method.End() }
+func (se *SuperExpression) String() string       { return se.TokenLiteral() + "." + se.Method.String() }
+
+// MemberExpression accesses a field or method of an object: p.x.
+type MemberExpression struct {
+	Token  token.Token // token.DOT
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Pos() token.Position  { return me.Object.Pos() }
+func (me *MemberExpression) End() token.Position  { return me.Member.End() }
+func (me *MemberExpression) String() string {
+	return me.Object.String() + "." + me.Member.String()
+}
+
+// ScopedExpression is a qualified access to a module member:
+// Geometry::area.
+type ScopedExpression struct {
+	Token  token.Token // token.MODACCESSOR
+	Left   Expression
+	Member *Identifier
+}
+
+func (se *ScopedExpression) expressionNode()      {}
+func (se *ScopedExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *ScopedExpression) Pos() token.Position  { return se.Left.Pos() }
+func (se *ScopedExpression) End() token.Position  { return se.Member.End() }
+func (se *ScopedExpression) String() string {
+	return se.Left.String() + "::" + se.Member.String()
+}
+
+// HashPair is one key: value entry of a HashLiteral.
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashLiteral is {key: value, ...}; Pairs are in source order.
+type HashLiteral struct {
+	Token  token.Token // token.LBRACE
+	Pairs  []HashPair
+	Rbrace token.Token
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position  { return hl.Rbrace.End }
+func (hl *HashLiteral) String() string {
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// IndexExpression is xs[i].
+type IndexExpression struct {
+	Token    token.Token // token.LBRACKET
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position  { return ie.Left.Pos() }
+func (ie *IndexExpression) End() token.Position  { return ie.Rbracket.End }
+func (ie *IndexExpression) String() string {
+	return "(" + ie.Left.String() + "[" + ie.Index.String() + "])"
+}
+
+// SliceExpression is xs[low:high]; either bound may be omitted, leaving
+// it nil.
+type SliceExpression struct {
+	Token    token.Token // token.LBRACKET
+	Left     Expression
+	Low      Expression
+	High     Expression
+	Rbracket token.Token
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Pos() token.Position  { return se.Left.Pos() }
+func (se *SliceExpression) End() token.Position  { return se.Rbracket.End }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Low != nil {
+		out.WriteString(se.Low.String())
+	}
+	out.WriteString(":")
+	if se.High != nil {
+		out.WriteString(se.High.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // token.LBRACE
 	Statements []Statement
+	Rbrace     token.Token
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position  { return bs.Rbrace.End }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -144,7 +450,8 @@ func (bs *BlockStatement) String() string {
 
 // VarStatement ...
 type VarStatement struct {
-	Token token.Token // token.VAR
+	Doc   *CommentGroup // comments directly above the statement, or nil
+	Token token.Token   // token.VAR
 	Name  *Identifier
 	Value Expression
 }
@@ -153,6 +460,13 @@ func (vStmt *VarStatement) statementNode() {}
 
 // TokenLiteral ...
 func (vStmt *VarStatement) TokenLiteral() string { return vStmt.Token.Literal }
+func (vStmt *VarStatement) Pos() token.Position  { return vStmt.Token.Pos }
+func (vStmt *VarStatement) End() token.Position {
+	if vStmt.Value != nil {
+		return vStmt.Value.End()
+	}
+	return vStmt.Name.End()
+}
 func (vStmt *VarStatement) String() string {
 	var out bytes.Buffer
 
@@ -168,6 +482,124 @@ func (vStmt *VarStatement) String() string {
 	return out.String()
 }
 
+// ValStatement declares an immutable binding.
+type ValStatement struct {
+	Doc   *CommentGroup // comments directly above the statement, or nil
+	Token token.Token   // token.VALUE
+	Name  *Identifier
+	Value Expression
+}
+
+func (vStmt *ValStatement) statementNode() {}
+
+// TokenLiteral ...
+func (vStmt *ValStatement) TokenLiteral() string { return vStmt.Token.Literal }
+func (vStmt *ValStatement) Pos() token.Position  { return vStmt.Token.Pos }
+func (vStmt *ValStatement) End() token.Position {
+	if vStmt.Value != nil {
+		return vStmt.Value.End()
+	}
+	return vStmt.Name.End()
+}
+func (vStmt *ValStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(vStmt.TokenLiteral() + " ")
+	out.WriteString(vStmt.Name.String())
+	out.WriteString(" = ")
+
+	if vStmt.Value != nil {
+		out.WriteString(vStmt.Value.String())
+	}
+
+	out.WriteString(";")
+	return out.String()
+}
+
+// LetStatement is the English-style immutable binding
+// let name be value [being definitions];
+// where the optional being clause introduces definitions that are only
+// visible while value is evaluated.
+type LetStatement struct {
+	Doc   *CommentGroup // comments directly above the statement, or nil
+	Token token.Token   // token.LET
+	Name  *Identifier
+	Value Expression
+	Being []*Binding
+}
+
+func (ls *LetStatement) statementNode() {}
+
+// TokenLiteral ...
+func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position {
+	if n := len(ls.Being); n > 0 {
+		return ls.Being[n-1].Value.End()
+	}
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	return ls.Name.End()
+}
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.String())
+	out.WriteString(" be ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	if len(ls.Being) > 0 {
+		bindings := []string{}
+		for _, b := range ls.Being {
+			bindings = append(bindings, b.String())
+		}
+
+		out.WriteString(" being ")
+		out.WriteString(strings.Join(bindings, ", "))
+	}
+
+	out.WriteString(";")
+	return out.String()
+}
+
+// Binding is a single definition of a being clause, either name = value
+// or, when Parameters is non-nil, the local function name(params) = value.
+type Binding struct {
+	Name       *Identifier
+	Parameters []*Identifier
+	Value      Expression
+}
+
+func (b *Binding) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(b.Name.String())
+
+	if b.Parameters != nil {
+		params := []string{}
+		for _, p := range b.Parameters {
+			params = append(params, p.String())
+		}
+
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(")")
+	}
+
+	out.WriteString(" = ")
+
+	if b.Value != nil {
+		out.WriteString(b.Value.String())
+	}
+
+	return out.String()
+}
+
 // ReturnStatement ...
 type ReturnStatement struct {
 	Token       token.Token // token.RETURN
@@ -178,6 +610,13 @@ func (rs *ReturnStatement) statementNode() {}
 
 // TokenLiteral ...
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -201,20 +640,124 @@ func (id *Identifier) expressionNode() {}
 
 // TokenLiteral ...
 func (id *Identifier) TokenLiteral() string { return id.Token.Literal }
+func (id *Identifier) Pos() token.Position  { return id.Token.Pos }
+func (id *Identifier) End() token.Position  { return id.Token.End }
 func (id *Identifier) String() string       { return id.Value }
 
 // IntegerLiteral ...
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int // the value when it does not fit in Value, otherwise nil
 }
 
 func (intLiteral *IntegerLiteral) expressionNode() {}
 
 // TokenLiteral ...
 func (intLiteral *IntegerLiteral) TokenLiteral() string { return intLiteral.Token.Literal }
+func (intLiteral *IntegerLiteral) Pos() token.Position  { return intLiteral.Token.Pos }
+func (intLiteral *IntegerLiteral) End() token.Position  { return intLiteral.Token.End }
 func (intLiteral *IntegerLiteral) String() string       { return intLiteral.Token.Literal }
 
+// FloatLiteral ...
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+// TokenLiteral ...
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position  { return fl.Token.End }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
+// StringLiteral ...
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode() {}
+
+// TokenLiteral ...
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.End }
+func (sl *StringLiteral) String() string       { return Quote(sl.Value) }
+
+// InterpolatedString is a string literal with embedded ${...}
+// expressions. Parts alternates between text, as *StringLiteral, and the
+// embedded expressions, starting and ending with text.
+type InterpolatedString struct {
+	Token token.Token // token.TMPLHEAD
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position  { return is.Parts[len(is.Parts)-1].End() }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(escape(part.(*StringLiteral).Value))
+			continue
+		}
+
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
+// Quote returns str as a double-quoted string literal.
+func Quote(str string) string {
+	return `"` + escape(str) + `"`
+}
+
+// escape is the inverse of the lexer's escape handling, so that a string
+// literal prints back as valid source.
+func escape(str string) string {
+	var out bytes.Buffer
+
+	for i, r := range str {
+		switch r {
+		case '\n':
+			out.WriteString(`\n`)
+		case '\t':
+			out.WriteString(`\t`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '$':
+			if i+1 < len(str) && str[i+1] == '{' {
+				out.WriteString(`\$`)
+			} else {
+				out.WriteRune(r)
+			}
+		default:
+			if r < ' ' || r == 0x7f {
+				out.WriteString(fmt.Sprintf(`\u{%x}`, r))
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+
+	return out.String()
+}
+
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
@@ -223,6 +766,8 @@ type FunctionLiteral struct {
 
 func (funl *FunctionLiteral) expressionNode()      {}
 func (funl *FunctionLiteral) TokenLiteral() string { return funl.Token.Literal }
+func (funl *FunctionLiteral) Pos() token.Position  { return funl.Token.Pos }
+func (funl *FunctionLiteral) End() token.Position  { return funl.Body.End() }
 func (funl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -248,11 +793,102 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) End() token.Position  { return b.Token.End }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+// BadExpression is a placeholder for an expression that could not be
+// parsed; it covers the source from Token up to To.
+type BadExpression struct {
+	Token token.Token // first token of the bad expression
+	To    token.Position
+}
+
+func (bad *BadExpression) expressionNode() {}
+
+// TokenLiteral ...
+func (bad *BadExpression) TokenLiteral() string { return bad.Token.Literal }
+func (bad *BadExpression) Pos() token.Position  { return bad.Token.Pos }
+func (bad *BadExpression) End() token.Position  { return bad.To }
+func (bad *BadExpression) String() string       { return "<bad expression>" }
+
+// BadStatement is a placeholder for a statement that could not be parsed,
+// including the tokens skipped while recovering from it.
+type BadStatement struct {
+	Token token.Token // first token of the bad statement
+	To    token.Position
+}
+
+func (bad *BadStatement) statementNode() {}
+
+// TokenLiteral ...
+func (bad *BadStatement) TokenLiteral() string { return bad.Token.Literal }
+func (bad *BadStatement) Pos() token.Position  { return bad.Token.Pos }
+func (bad *BadStatement) End() token.Position  { return bad.To }
+func (bad *BadStatement) String() string       { return "<bad statement>" }
+
+// Comment is a single // or /* */ comment.
+type Comment struct {
+	Token token.Token // token.COMMENT
+}
+
+// TokenLiteral ...
+func (c *Comment) TokenLiteral() string { return c.Token.Literal }
+func (c *Comment) Pos() token.Position  { return c.Token.Pos }
+func (c *Comment) End() token.Position  { return c.Token.End }
+func (c *Comment) String() string       { return c.Token.Literal }
+
+// CommentGroup is a run of comments with no blank line or other token
+// between them.
+type CommentGroup struct {
+	List []*Comment
+}
+
+// TokenLiteral ...
+func (g *CommentGroup) TokenLiteral() string { return g.List[0].TokenLiteral() }
+func (g *CommentGroup) Pos() token.Position  { return g.List[0].Pos() }
+func (g *CommentGroup) End() token.Position  { return g.List[len(g.List)-1].End() }
+func (g *CommentGroup) String() string {
+	lines := []string{}
+	for _, c := range g.List {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text returns the text of the comments without their delimiters, one
+// line per source line, with surrounding blank lines removed.
+func (g *CommentGroup) Text() string {
+	lines := []string{}
+	for _, c := range g.List {
+		text := c.Token.Literal
+		if strings.HasPrefix(text, "//") {
+			lines = append(lines, strings.TrimSpace(text[2:]))
+			continue
+		}
+
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			// Block comments commonly start their lines with '*'.
+			line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			lines = append(lines, line)
+		}
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Program ...
 type Program struct {
 	Statements []Statement
+	Comments   []*CommentGroup // all comments, in source order, when scanned
 }
 
 // TokenLiteral ...
@@ -264,6 +900,22 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+// Pos ...
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+// End ...
+func (p *Program) End() token.Position {
+	if n := len(p.Statements); n > 0 {
+		return p.Statements[n-1].End()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
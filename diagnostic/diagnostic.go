@@ -0,0 +1,94 @@
+package diagnostic
+
+import (
+	"clint/token"
+	"fmt"
+)
+
+// Severity ...
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// Codes identify each kind of diagnostic, so that tools and tests do not
+// have to match on message text.
+const (
+	IllegalCharacter    = "E0001"
+	UnterminatedString  = "E0002"
+	InvalidEscape       = "E0003"
+	UnterminatedComment = "E0004"
+	InvalidUTF8         = "E0005"
+	MalformedNumber     = "E0006"
+	UnexpectedToken     = "E0100"
+	ExpectedExpression  = "E0101"
+	InvalidInteger      = "E0102"
+	InvalidFloat        = "E0103"
+	InvalidAssignTarget = "E0104"
+	AssignToVal         = "E0105"
+	InvalidImportName   = "E0106"
+	CyclicInheritance   = "E0107"
+	DuplicateDefinition = "E0108"
+)
+
+// Span is the half-open source range [Start, End).
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+// TokenSpan returns the span covered by tok.
+func TokenSpan(tok token.Token) Span { return Span{Start: tok.Pos, End: tok.End} }
+
+// Label points at a secondary location that helps explain a diagnostic.
+type Label struct {
+	Span    Span
+	Message string
+}
+
+// Suggestion is a fix-it: replacing the source in Span by Replacement
+// resolves the diagnostic. An empty Span is an insertion.
+type Suggestion struct {
+	Span        Span
+	Replacement string
+	Message     string
+}
+
+// Diagnostic ...
+type Diagnostic struct {
+	Severity   Severity
+	Code       string
+	Message    string
+	Span       Span
+	Labels     []Label
+	Suggestion *Suggestion
+}
+
+// Errorf returns an error diagnostic for span.
+func Errorf(code string, span Span, format string, a ...interface{}) Diagnostic {
+	return Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Span:     span,
+	}
+}
+
+// Error formats d on a single line, prefixed with its position.
+func (d Diagnostic) Error() string {
+	return d.Span.Start.String() + ": " + d.Message
+}
@@ -0,0 +1,97 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Render writes d to w in the style of rustc, quoting the source lines it
+// refers to and underlining the primary span with carets and any labels
+// with dashes:
+//
+//	error[E0100]: expected next token to be ), got EOF instead
+//	 --> 1:9
+//	  |
+//	1 | add(1, 2
+//	  |    - unclosed delimiter
+//	  |         ^
+//	  |
+//	  = help: insert `)`
+func Render(w io.Writer, source string, d Diagnostic) {
+	lines := strings.Split(source, "\n")
+
+	type mark struct {
+		span    Span
+		char    string
+		message string
+	}
+
+	marks := []mark{{span: d.Span, char: "^"}}
+	for _, label := range d.Labels {
+		marks = append(marks, mark{span: label.Span, char: "-", message: label.Message})
+	}
+
+	sort.SliceStable(marks, func(i, j int) bool {
+		return marks[i].span.Start.Offset < marks[j].span.Start.Offset
+	})
+
+	width := 0
+	for _, m := range marks {
+		if n := len(strconv.Itoa(m.span.Start.Line)); n > width {
+			width = n
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+	fmt.Fprintf(w, "%s: %s\n", header, d.Message)
+	fmt.Fprintf(w, "%s--> %s\n", gutter, d.Span.Start)
+	fmt.Fprintf(w, "%s |\n", gutter)
+
+	printed := 0
+	for _, m := range marks {
+		line := m.span.Start.Line
+		if line < 1 || line > len(lines) {
+			continue
+		}
+
+		text := strings.Replace(lines[line-1], "\t", " ", -1)
+		if line != printed {
+			fmt.Fprintf(w, "%*d | %s\n", width, line, text)
+			printed = line
+		}
+
+		start := m.span.Start.Column
+		if start < 1 {
+			start = 1
+		}
+
+		// Spans running past the end of the line are underlined up to it.
+		length := 1
+		if m.span.End.Line == line {
+			length = m.span.End.Column - start
+		} else if m.span.End.Line > line {
+			length = len([]rune(text)) - start + 1
+		}
+		if length < 1 {
+			length = 1
+		}
+
+		underline := strings.Repeat(" ", start-1) + strings.Repeat(m.char, length)
+		if m.message != "" {
+			underline += " " + m.message
+		}
+		fmt.Fprintf(w, "%s | %s\n", gutter, underline)
+	}
+
+	if d.Suggestion != nil {
+		fmt.Fprintf(w, "%s |\n", gutter)
+		fmt.Fprintf(w, "%s = help: %s\n", gutter, d.Suggestion.Message)
+	}
+}
@@ -0,0 +1,101 @@
+package diagnostic
+
+import (
+	"bytes"
+	"clint/token"
+	"testing"
+)
+
+func pos(line, column, offset int) token.Position {
+	return token.Position{Offset: offset, Line: line, Column: column}
+}
+
+func TestError(test *testing.T) {
+	d := Errorf(UnexpectedToken, Span{Start: pos(2, 5, 12)}, "expected %s", ")")
+
+	if d.Error() != "2:5: expected )" {
+		test.Errorf("wrong Error(). got=%q", d.Error())
+	}
+
+	if d.Severity != Error || d.Code != UnexpectedToken {
+		test.Errorf("wrong severity or code. got=%s %s", d.Severity, d.Code)
+	}
+}
+
+func TestRender(test *testing.T) {
+	tests := []struct {
+		source   string
+		d        Diagnostic
+		expected string
+	}{
+		{
+			"var x = @",
+			Errorf(IllegalCharacter, Span{Start: pos(1, 9, 8), End: pos(1, 10, 9)}, "illegal character '@'"),
+			"error[E0001]: illegal character '@'\n" +
+				" --> 1:9\n" +
+				"  |\n" +
+				"1 | var x = @\n" +
+				"  |         ^\n",
+		},
+		{
+			"add(1, 2",
+			Diagnostic{
+				Code:    UnexpectedToken,
+				Message: "expected next token to be ), got EOF instead",
+				Span:    Span{Start: pos(1, 9, 8), End: pos(1, 9, 8)},
+				Labels: []Label{
+					{Span: Span{Start: pos(1, 4, 3), End: pos(1, 5, 4)}, Message: "unclosed delimiter"},
+				},
+				Suggestion: &Suggestion{
+					Span:        Span{Start: pos(1, 9, 8), End: pos(1, 9, 8)},
+					Replacement: ")",
+					Message:     "insert `)`",
+				},
+			},
+			"error[E0100]: expected next token to be ), got EOF instead\n" +
+				" --> 1:9\n" +
+				"  |\n" +
+				"1 | add(1, 2\n" +
+				"  |    - unclosed delimiter\n" +
+				"  |         ^\n" +
+				"  |\n" +
+				"  = help: insert `)`\n",
+		},
+		{
+			"val total = 1\n\n\n\n\n\n\n\n\ntotal = 2",
+			Diagnostic{
+				Code:    AssignToVal,
+				Message: "cannot assign to val binding total",
+				Span:    Span{Start: pos(10, 1, 23), End: pos(10, 6, 28)},
+				Labels: []Label{
+					{Span: Span{Start: pos(1, 5, 4), End: pos(1, 10, 9)}, Message: "declared here"},
+				},
+			},
+			"error[E0105]: cannot assign to val binding total\n" +
+				"  --> 10:1\n" +
+				"   |\n" +
+				" 1 | val total = 1\n" +
+				"   |     ----- declared here\n" +
+				"10 | total = 2\n" +
+				"   | ^^^^^\n",
+		},
+		{
+			"x",
+			Diagnostic{Severity: Warning, Message: "unused", Span: Span{Start: pos(1, 1, 0), End: pos(1, 2, 1)}},
+			"warning: unused\n" +
+				" --> 1:1\n" +
+				"  |\n" +
+				"1 | x\n" +
+				"  | ^\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Render(&out, tt.source, tt.d)
+
+		if out.String() != tt.expected {
+			test.Errorf("wrong rendering for %q.\nexpected=\n%s\ngot=\n%s", tt.source, tt.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"clint/object"
+	"unicode/utf8"
+)
+
+var builtins = map[string]*object.Builtin{
+	"len": {
+		Name: "len",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Keys))}
+			case *object.String:
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			default:
+				return newError("argument to `len` not supported, got %s", arg.Type())
+			}
+		},
+	},
+	"keys": {
+		Name: "keys",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `keys` must be HASH, got %s", args[0].Type())
+			}
+
+			keys := []object.Object{}
+			for _, key := range hash.Keys {
+				keys = append(keys, hash.Pairs[key].Key)
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": {
+		Name: "values",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `values` must be HASH, got %s", args[0].Type())
+			}
+
+			values := []object.Object{}
+			for _, key := range hash.Keys {
+				values = append(values, hash.Pairs[key].Value)
+			}
+			return &object.Array{Elements: values}
+		},
+	},
+	"is_a?": {
+		Name: "is_a?",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			class, ok := args[1].(*object.Class)
+			if !ok {
+				return newError("second argument to `is_a?` must be CLASS, got %s", args[1].Type())
+			}
+
+			instance, ok := args[0].(*object.Instance)
+			return object.NativeBoolToBooleanObject(ok && instance.Class.IsSubclassOf(class))
+		},
+	},
+	"type": {
+		Name: "type",
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+}
@@ -0,0 +1,1083 @@
+package evaluator
+
+import (
+	"bytes"
+	"clint/ast"
+	"clint/object"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Eval ...
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+
+	// Statements
+	case *ast.Program:
+		return evalProgram(node, env)
+
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{Value: object.NULL}
+		}
+
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+
+	case *ast.VarStatement:
+		if env.IsImmutable(node.Name.Value) {
+			return newError("cannot redeclare val binding %s", node.Name.Value)
+		}
+
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.Set(node.Name.Value, val)
+
+	case *ast.ValStatement:
+		if env.IsImmutable(node.Name.Value) {
+			return newError("cannot redeclare val binding %s", node.Name.Value)
+		}
+
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.SetImmutable(node.Name.Value, val)
+
+	case *ast.LetStatement:
+		return evalLetStatement(node, env)
+
+	case *ast.ModuleStatement:
+		return evalModuleStatement(node, env)
+
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+
+	case *ast.ClassStatement:
+		return evalClassStatement(node, env)
+
+	case *ast.BadStatement:
+		return newError("cannot evaluate statement with syntax errors at %s", node.Pos())
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInteger{Value: node.Big}
+		}
+		return &object.Integer{Value: node.Value}
+
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
+	case *ast.Boolean:
+		return object.NativeBoolToBooleanObject(node.Value)
+
+	case *ast.PrefixExpression:
+		right := Eval(node.RightHand, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+
+	case *ast.InfixExpression:
+		left := Eval(node.LeftHand, env)
+		if isError(left) {
+			return left
+		}
+
+		right := Eval(node.RightHand, env)
+		if isError(right) {
+			return right
+		}
+
+		return evalInfixExpression(node.Operator, left, right)
+
+	case *ast.LogicalExpression:
+		return evalLogicalExpression(node, env)
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+
+	case *ast.FunctionLiteral:
+		params := node.Parameters
+		body := node.Body
+		return &object.Function{Parameters: params, Body: body, Env: env}
+
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		return evalIndexExpression(left, index)
+
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+
+	case *ast.ScopedExpression:
+		return evalScopedExpression(node, env)
+
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+
+	case *ast.SuperExpression:
+		return evalSuperExpression(node, env)
+
+	case *ast.BadExpression:
+		return newError("cannot evaluate expression with syntax errors at %s", node.Pos())
+
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+
+		return applyFunction(function, args)
+	}
+
+	return nil
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+
+	return result
+}
+
+// evalBlockStatement evaluates to the value of the last statement in
+// block, or to null if the block is empty or ends in a declaration.
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+
+	if result == nil {
+		return object.NULL
+	}
+	return result
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!", "not":
+		return evalBangOperatorExpression(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalBangOperatorExpression(right object.Object) object.Object {
+	return object.NativeBoolToBooleanObject(!object.IsTruthy(right))
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	if operator == "equal" {
+		operator = "=="
+	}
+
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		// Mixed Integer and Float operands are promoted to Float.
+		return evalFloatInfixExpression(operator, toFloat(left), toFloat(right))
+	case operator == "==":
+		return object.NativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return object.NativeBoolToBooleanObject(left != right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalIntegerInfixExpression evaluates operators on int64 values, and
+// switches to arbitrary precision when an operand is a BigInteger or
+// the result overflows.
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftInt, leftOk := left.(*object.Integer)
+	rightInt, rightOk := right.(*object.Integer)
+	if !leftOk || !rightOk {
+		return evalBigIntegerInfixExpression(operator, toBig(left), toBig(right))
+	}
+	leftVal, rightVal := leftInt.Value, rightInt.Value
+
+	switch operator {
+	case "+":
+		if sum, ok := addInt64(leftVal, rightVal); ok {
+			return &object.Integer{Value: sum}
+		}
+	case "-":
+		if diff, ok := addInt64(leftVal, -rightVal); ok && rightVal != math.MinInt64 {
+			return &object.Integer{Value: diff}
+		}
+	case "*":
+		if product, ok := mulInt64(leftVal, rightVal); ok {
+			return &object.Integer{Value: product}
+		}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
+		if leftVal != math.MinInt64 || rightVal != -1 {
+			return &object.Integer{Value: leftVal / rightVal}
+		}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %d %% %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "^":
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		if power, ok := integerPow(leftVal, rightVal); ok {
+			return &object.Integer{Value: power}
+		}
+	case "<":
+		return object.NativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return object.NativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return object.NativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return object.NativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	// The result overflows an int64.
+	return evalBigIntegerInfixExpression(operator, big.NewInt(leftVal), big.NewInt(rightVal))
+}
+
+// maxBigExponent bounds the exponents accepted by ^ on big integers, so a
+// typo cannot make the interpreter compute a number of gigabytes.
+const maxBigExponent = 1 << 24
+
+// evalBigIntegerInfixExpression evaluates operators with arbitrary
+// precision. Results that fit in an int64 are demoted back to Integer.
+func evalBigIntegerInfixExpression(operator string, left, right *big.Int) object.Object {
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(left, right))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(left, right))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(left, right))
+	case "/":
+		if right.Sign() == 0 {
+			return newError("division by zero: %s / %s", left, right)
+		}
+		return object.NewInteger(new(big.Int).Quo(left, right))
+	case "%":
+		if right.Sign() == 0 {
+			return newError("modulo by zero: %s %% %s", left, right)
+		}
+		return object.NewInteger(new(big.Int).Rem(left, right))
+	case "^":
+		if right.Sign() < 0 {
+			return evalFloatInfixExpression(operator, bigToFloat(left), bigToFloat(right))
+		}
+		if left.CmpAbs(big.NewInt(1)) > 0 && (!right.IsInt64() || right.Int64() > maxBigExponent) {
+			return newError("exponent too large: %s ^ %s", left, right)
+		}
+		return object.NewInteger(new(big.Int).Exp(left, right, nil))
+	case "<":
+		return object.NativeBoolToBooleanObject(left.Cmp(right) < 0)
+	case ">":
+		return object.NativeBoolToBooleanObject(left.Cmp(right) > 0)
+	case "==":
+		return object.NativeBoolToBooleanObject(left.Cmp(right) == 0)
+	case "!=":
+		return object.NativeBoolToBooleanObject(left.Cmp(right) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+	}
+}
+
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Float).Value
+	rightVal := right.(*object.Float).Value
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "^":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return object.NativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return object.NativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return object.NativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return object.NativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "==":
+		return object.NativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return object.NativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out bytes.Buffer
+
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+
+		if str, ok := val.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString(val.Inspect())
+		}
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) *object.Float {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return &object.Float{Value: float64(obj.Value)}
+	case *object.BigInteger:
+		return bigToFloat(obj.Value)
+	}
+	return obj.(*object.Float)
+}
+
+func bigToFloat(value *big.Int) *object.Float {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return &object.Float{Value: f}
+}
+
+func toBig(obj object.Object) *big.Int {
+	if integer, ok := obj.(*object.Integer); ok {
+		return big.NewInt(integer.Value)
+	}
+	return obj.(*object.BigInteger).Value
+}
+
+// addInt64 returns a + b, reporting false if it overflows.
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (sum > a) == (b > 0)
+}
+
+// mulInt64 returns a * b, reporting false if it overflows.
+func mulInt64(a, b int64) (int64, bool) {
+	product := a * b
+	if a != 0 && (product/a != b || a == -1 && b == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// integerPow computes base ^ exp for exp >= 0 by repeated squaring,
+// reporting false if the result overflows.
+func integerPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			if result, ok = mulInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+
+		exp >>= 1
+		if exp > 0 {
+			if base, ok = mulInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// evalLogicalExpression evaluates `and` and `or` to the operand that
+// decided the result, evaluating the right hand side only when needed.
+func evalLogicalExpression(node *ast.LogicalExpression, env *object.Environment) object.Object {
+	left := Eval(node.LeftHand, env)
+	if isError(left) {
+		return left
+	}
+
+	switch node.Operator {
+	case "and":
+		if !object.IsTruthy(left) {
+			return left
+		}
+	case "or":
+		if object.IsTruthy(left) {
+			return left
+		}
+	default:
+		return newError("unknown operator: %s", node.Operator)
+	}
+
+	return Eval(node.RightHand, env)
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if object.IsTruthy(condition) {
+		return Eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return Eval(ie.Alternative, env)
+	} else {
+		return object.NULL
+	}
+}
+
+func evalLetStatement(node *ast.LetStatement, env *object.Environment) object.Object {
+	if env.IsImmutable(node.Name.Value) {
+		return newError("cannot redeclare val binding %s", node.Name.Value)
+	}
+
+	scope := env
+	if len(node.Being) > 0 {
+		scope = object.NewEnclosedEnvironment(env)
+
+		for _, binding := range node.Being {
+			val := evalBinding(binding, scope)
+			if isError(val) {
+				return val
+			}
+			scope.SetImmutable(binding.Name.Value, val)
+		}
+	}
+
+	val := Eval(node.Value, scope)
+	if isError(val) {
+		return val
+	}
+
+	env.SetImmutable(node.Name.Value, val)
+	return nil
+}
+
+func evalBinding(binding *ast.Binding, env *object.Environment) object.Object {
+	if binding.Parameters == nil {
+		return Eval(binding.Value, env)
+	}
+
+	body := &ast.BlockStatement{
+		Statements: []ast.Statement{&ast.ExpressionStatement{Expression: binding.Value}},
+	}
+	return &object.Function{Parameters: binding.Parameters, Body: body, Env: env}
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.IndexExpression:
+		return evalIndexAssignment(target, node.Value, env)
+	case *ast.MemberExpression:
+		return evalMemberAssignment(target, node.Value, env)
+	}
+
+	ident, ok := node.Target.(*ast.Identifier)
+	if !ok {
+		return newError("invalid assignment target: %s", node.Target.String())
+	}
+
+	scope, ok := env.Resolve(ident.Value)
+	if !ok {
+		return newError("identifier not found: %s", ident.Value)
+	}
+
+	if scope.IsImmutable(ident.Value) {
+		return newError("cannot assign to val binding %s", ident.Value)
+	}
+
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	return scope.Set(ident.Value, val)
+}
+
+// evalIndexAssignment evaluates xs[i] = value, replacing an existing
+// element of an array or adding or replacing the entry of a hash.
+func evalIndexAssignment(target *ast.IndexExpression, valueNode ast.Expression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	value := Eval(valueNode, env)
+	if isError(value) {
+		return value
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		if index.Type() != object.INTEGER_OBJ {
+			return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+		}
+
+		i, ok := normalizeIndex(index, len(left.Elements))
+		if !ok || i >= len(left.Elements) {
+			return newError("index out of range: %s (length %d)", index.Inspect(), len(left.Elements))
+		}
+		left.Elements[i] = value
+
+	case *object.Hash:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+
+	return value
+}
+
+// evalModuleStatement evaluates the body of a module in a scope of its
+// own, enclosed in the one the module is declared in, and binds the
+// resulting module as a val.
+func evalModuleStatement(node *ast.ModuleStatement, env *object.Environment) object.Object {
+	name := node.Name.Value
+	if env.IsImmutable(name) {
+		return newError("cannot redeclare val binding %s", name)
+	}
+
+	moduleEnv := object.NewEnclosedEnvironment(env)
+	if result := evalModuleBody(name, node.Body.Statements, moduleEnv); isError(result) {
+		return result
+	}
+
+	env.SetImmutable(name, &object.Module{Name: name, Env: moduleEnv})
+	return nil
+}
+
+// evalModuleBody evaluates the statements of module name in env, which
+// becomes the scope of its members. A return would leave the module
+// half-evaluated, so it is an error.
+func evalModuleBody(name string, statements []ast.Statement, env *object.Environment) object.Object {
+	result := evalBlockStatement(&ast.BlockStatement{Statements: statements}, env)
+	if isError(result) {
+		return result
+	}
+	if result != nil && result.Type() == object.RETURN_VALUE_OBJ {
+		return newError("return outside function in module %s", name)
+	}
+	return nil
+}
+
+// evalClassStatement binds a class as a val. Its methods close over the
+// scope the class is declared in, extended with super bound to the
+// superclass, or to null if there is none.
+func evalClassStatement(node *ast.ClassStatement, env *object.Environment) object.Object {
+	name := node.Name.Value
+	if env.IsImmutable(name) {
+		return newError("cannot redeclare val binding %s", name)
+	}
+
+	class := &object.Class{Name: name, Methods: map[string]*object.Function{}}
+	methodEnv := object.NewEnclosedEnvironment(env)
+	methodEnv.SetImmutable("super", object.NULL)
+
+	if node.Superclass != nil {
+		superclass := Eval(node.Superclass, env)
+		if isError(superclass) {
+			return superclass
+		}
+
+		parent, ok := superclass.(*object.Class)
+		if !ok {
+			return newError("superclass of %s must be CLASS, got %s", name, superclass.Type())
+		}
+		class.Superclass = parent
+		methodEnv.SetImmutable("super", parent)
+	}
+
+	for _, method := range node.Methods {
+		class.Methods[method.Name.Value] = &object.Function{
+			Parameters: method.Function.Parameters,
+			Body:       method.Function.Body,
+			Env:        methodEnv,
+		}
+	}
+
+	env.SetImmutable(name, class)
+	return nil
+}
+
+// evalMemberExpression resolves obj.member to a field of the instance or,
+// failing that, to one of its methods bound to it.
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	left := Eval(node.Object, env)
+	if isError(left) {
+		return left
+	}
+
+	instance, ok := left.(*object.Instance)
+	if !ok {
+		return newError("member access not supported: %s.%s", left.Type(), node.Member.Value)
+	}
+
+	name := node.Member.Value
+	if value, ok := instance.Get(name); ok {
+		return value
+	}
+	if method, ok := instance.Class.FindMethod(name); ok {
+		return &object.BoundMethod{Receiver: instance, Name: name, Method: method}
+	}
+
+	return newError("undefined member %s of %s", name, instance.Class.Name)
+}
+
+// evalSuperExpression resolves super.method to the method inherited by
+// the class the running method is declared in, bound to self.
+func evalSuperExpression(node *ast.SuperExpression, env *object.Environment) object.Object {
+	superclass, _ := env.Get("super")
+	self, _ := env.Get("self")
+
+	instance, ok := self.(*object.Instance)
+	if !ok || superclass == nil {
+		return newError("super used outside a method")
+	}
+
+	class, ok := superclass.(*object.Class)
+	if !ok {
+		return newError("super used in a class without a superclass")
+	}
+
+	name := node.Method.Value
+	method, ok := class.FindMethod(name)
+	if !ok {
+		return newError("undefined method %s in superclass %s", name, class.Name)
+	}
+
+	return &object.BoundMethod{Receiver: instance, Name: name, Method: method}
+}
+
+// evalMemberAssignment evaluates obj.field = value, adding the field to
+// the instance if it does not have it yet.
+func evalMemberAssignment(target *ast.MemberExpression, valueNode ast.Expression, env *object.Environment) object.Object {
+	left := Eval(target.Object, env)
+	if isError(left) {
+		return left
+	}
+
+	instance, ok := left.(*object.Instance)
+	if !ok {
+		return newError("member assignment not supported: %s.%s", left.Type(), target.Member.Value)
+	}
+
+	if _, ok := instance.Class.FindMethod(target.Member.Value); ok {
+		return newError("cannot assign to method %s of %s", target.Member.Value, instance.Class.Name)
+	}
+
+	value := Eval(valueNode, env)
+	if isError(value) {
+		return value
+	}
+
+	instance.Set(target.Member.Value, value)
+	return value
+}
+
+// evalScopedExpression resolves Module::member. Members whose name starts
+// with an underscore are private and only visible inside the module.
+func evalScopedExpression(node *ast.ScopedExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	module, ok := left.(*object.Module)
+	if !ok {
+		return newError("%s is not a module: %s", node.Left.String(), left.Type())
+	}
+
+	name := node.Member.Value
+	if object.IsPrivateName(name) {
+		return newError("%s is private to module %s", name, module.Name)
+	}
+
+	member, ok := module.Env.GetLocal(name)
+	if !ok {
+		return newError("module %s has no member %s", module.Name, name)
+	}
+	return member
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+
+	return newError("identifier not found: %s", node.Value)
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(pair.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		hash.Set(hashKey, value)
+	}
+
+	return hash
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left.(*object.Array), index)
+	case left.Type() == object.HASH_OBJ:
+		return evalHashIndexExpression(left.(*object.Hash), index)
+	default:
+		return newError("index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
+// evalArrayIndexExpression returns xs[i]; a negative i counts from the
+// end, so xs[-1] is the last element.
+func evalArrayIndexExpression(array *object.Array, index object.Object) object.Object {
+	length := len(array.Elements)
+
+	i, ok := normalizeIndex(index, length)
+	if !ok || i >= length {
+		return newError("index out of range: %s (length %d)", index.Inspect(), length)
+	}
+
+	return array.Elements[i]
+}
+
+// evalHashIndexExpression returns h[key], or null if key is missing.
+func evalHashIndexExpression(hash *object.Hash, index object.Object) object.Object {
+	key, ok := index.(object.Hashable)
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hash.Get(key)
+	if !ok {
+		return object.NULL
+	}
+	return pair.Value
+}
+
+// evalSliceExpression returns xs[low:high], the elements from low up to
+// but excluding high. Omitted bounds default to the start and the end,
+// and negative ones count from the end.
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	array, ok := left.(*object.Array)
+	if !ok {
+		return newError("slice operator not supported: %s", left.Type())
+	}
+	length := len(array.Elements)
+
+	low, high := 0, length
+	for _, bound := range []struct {
+		exp   ast.Expression
+		value *int
+	}{{node.Low, &low}, {node.High, &high}} {
+		if bound.exp == nil {
+			continue
+		}
+
+		index := Eval(bound.exp, env)
+		if isError(index) {
+			return index
+		}
+		if index.Type() != object.INTEGER_OBJ {
+			return newError("slice bound must be INTEGER, got %s", index.Type())
+		}
+
+		i, ok := normalizeIndex(index, length)
+		if !ok || i > length {
+			return newError("slice bound out of range: %s (length %d)", index.Inspect(), length)
+		}
+		*bound.value = i
+	}
+
+	if low > high {
+		return newError("invalid slice bounds: %d > %d", low, high)
+	}
+
+	elements := make([]object.Object, high-low)
+	copy(elements, array.Elements[low:high])
+	return &object.Array{Elements: elements}
+}
+
+// normalizeIndex turns an integer index into an offset from the start of
+// a sequence of the given length, counting negative indices from the end.
+// It reports false if the offset falls outside [0, length].
+func normalizeIndex(index object.Object, length int) (int, bool) {
+	integer, ok := index.(*object.Integer)
+	if !ok {
+		// A BigInteger is out of range of any sequence.
+		return 0, false
+	}
+
+	i := integer.Value
+	if i < 0 {
+		i += int64(length)
+	}
+	if i < 0 || i > int64(length) {
+		return 0, false
+	}
+	return int(i), true
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+
+	return result
+}
+
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		return applyUserFunction(fn, args)
+	case *object.Builtin:
+		return fn.Fn(args...)
+	case *object.BoundMethod:
+		return applyMethod(fn.Receiver, fn.Method, args)
+	case *object.Class:
+		return instantiate(fn, args)
+	default:
+		return newError("not a function: %s", fn.Type())
+	}
+}
+
+func applyUserFunction(function *object.Function, args []object.Object) object.Object {
+	if len(args) != len(function.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d", len(function.Parameters), len(args))
+	}
+
+	extendedEnv := extendFunctionEnv(function, args)
+	evaluated := Eval(function.Body, extendedEnv)
+	return unwrapReturnValue(evaluated)
+}
+
+// applyMethod calls method with self bound to receiver.
+func applyMethod(receiver *object.Instance, method *object.Function, args []object.Object) object.Object {
+	if len(args) != len(method.Parameters) {
+		return newError("wrong number of arguments: want=%d, got=%d", len(method.Parameters), len(args))
+	}
+
+	extendedEnv := extendFunctionEnv(method, args)
+	extendedEnv.SetImmutable("self", receiver)
+	evaluated := Eval(method.Body, extendedEnv)
+	return unwrapReturnValue(evaluated)
+}
+
+// instantiate creates an instance of class and initializes it by calling
+// its init method with args. What init returns is discarded.
+func instantiate(class *object.Class, args []object.Object) object.Object {
+	instance := object.NewInstance(class)
+
+	init, ok := class.FindMethod("init")
+	if !ok {
+		if len(args) != 0 {
+			return newError("wrong number of arguments: want=0, got=%d", len(args))
+		}
+		return instance
+	}
+
+	if result := applyMethod(instance, init, args); isError(result) {
+		return result
+	}
+	return instance
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(fn.Env)
+
+	for i, param := range fn.Parameters {
+		env.Set(param.Value, args[i])
+	}
+
+	return env
+}
+
+// unwrapReturnValue turns what a function body evaluated to into the
+// value of the call, which is null if there is none.
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	if obj == nil {
+		return object.NULL
+	}
+	return obj
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
@@ -0,0 +1,1329 @@
+package evaluator
+
+import (
+	"clint/lexer"
+	"clint/object"
+	"clint/parser"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testEval(test *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		test.Fatalf("%q: parser has errors: %q", input, errors)
+	}
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+// testEvalWithSyntaxErrors is testEval for input the parser rejects,
+// evaluating whatever it recovered.
+func testEvalWithSyntaxErrors(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+
+	return Eval(program, env)
+}
+
+func testErrorObject(test *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.Error)
+	if !ok {
+		test.Errorf("object is not Error. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Message != expected {
+		test.Errorf("wrong error message. expected=%q, got=%q", expected, result.Message)
+		return false
+	}
+
+	return true
+}
+
+func testIntegerObject(test *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		test.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		test.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testBooleanObject(test *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		test.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		test.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testNullObject(test *testing.T, obj object.Object) bool {
+	if obj != object.NULL {
+		test.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+		return false
+	}
+	return true
+}
+
+func TestEvalIntegerExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		testIntegerObject(test, evaluated, tt.expected)
+	}
+}
+
+func TestEvalBooleanExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"(1 < 2) == true", true},
+		{"(1 > 2) == true", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		testBooleanObject(test, evaluated, tt.expected)
+	}
+}
+
+func TestBangOperator(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		testBooleanObject(test, evaluated, tt.expected)
+	}
+}
+
+func TestIfElseExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(test, evaluated, int64(integer))
+		} else {
+			testNullObject(test, evaluated)
+		}
+	}
+}
+
+func TestErrorHandling(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "unknown operator: -BOOLEAN"},
+		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
+		{"foobar", "identifier not found: foobar"},
+		{"10 / 0", "division by zero: 10 / 0"},
+		{"5(1)", "not a function: INTEGER"},
+		{"fun(x) { x; }(1, 2)", "wrong number of arguments: want=1, got=2"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestSyntaxErrorHandling(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1; var = 5", "cannot evaluate statement with syntax errors at 1:4"},
+		{"1 + )", "cannot evaluate expression with syntax errors at 1:5"},
+		// Rejected by the parser; at runtime A is not defined yet.
+		{"class A < A { }", "identifier not found: A"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEvalWithSyntaxErrors(tt.input), tt.expectedMessage)
+	}
+}
+
+func TestFunctionObject(test *testing.T) {
+	input := "fun(x) { x + 2; };"
+
+	evaluated := testEval(test, input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		test.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		test.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		test.Fatalf("parameter is not 'x'. got=%q", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+
+	if fn.Body.String() != expectedBody {
+		test.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"fun(x) { x; }(5)", 5},
+		{"fun(x, y) { x + y; }(5, 5)", 10},
+		{"fun(x, y) { x * y; }(5, 2 + 3)", 25},
+		{"fun() { 5; }()", 5},
+		{"fun(x) { if (x > 1) { x } else { 0 } }(3)", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestBuiltinFunctions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type(1)", "INTEGER"},
+		{"type(true)", "BOOLEAN"},
+		{"type(fun() { 1 })", "FUNCTION"},
+		{"type(type)", "BUILTIN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			test.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if str.Value != tt.expected {
+			test.Errorf("wrong type name. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
+func TestClosures(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"fun(x) { fun(y) { x + y } }(2)(3)", 5},
+		{"fun(x) { fun(y) { fun(z) { x * y + z } } }(2)(3)(4)", 10},
+		{"fun(x) { fun(x) { x } }(1)(2)", 2},
+		{"fun(x) { fun(f) { f(10) } }(1)(fun(y) { y + 1 })", 11},
+		{"fun(adder) { adder(2)(40) }(fun(x) { fun(y) { x + y } })", 42},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestParametersDoNotLeak(test *testing.T) {
+	input := "fun(x) { x }(5); x"
+
+	testErrorObject(test, testEval(test, input), "identifier not found: x")
+}
+
+func TestVarStatements(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var a = 5; a;", 5},
+		{"var a = 5 * 5; a;", 25},
+		{"var a = 5; var b = a; b;", 5},
+		{"var a = 5; var b = a; var c = a + b + 5; c;", 15},
+		{"var a = 5;\nvar b = a * 2;\nb", 10},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestReturnStatements(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{"if (10 > 1) { if (10 > 1) { return 10; } return 1; }", 10},
+		{"var f = fun(x) { return x; x + 10; }; f(10);", 10},
+		{"var f = fun(x) { if (x > 5) { return 1 } return 2 }; f(10) + f(1)", 3},
+		{"fun() { return; 10 }()", nil},
+		{"fun() { return }()", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(test, evaluated, int64(integer))
+		} else {
+			testNullObject(test, evaluated)
+		}
+	}
+}
+
+func TestRecursiveFunction(test *testing.T) {
+	input := `
+	var fib = fun(n) {
+		if (n < 2) { return n; }
+		fib(n - 1) + fib(n - 2);
+	};
+	fib(15);
+	`
+
+	testIntegerObject(test, testEval(test, input), 610)
+}
+
+func TestFunctionLocalsDoNotLeak(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var x = 1; fun() { var x = 2; x }(); x", 1},
+		{"var x = 1; fun() { var x = 2; x }()", 2},
+		{"var y = 1; fun(y) { y }(7) + y", 8},
+		{"var newAdder = fun(x) { fun(y) { x + y } }; var addTwo = newAdder(2); addTwo(3);", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+
+	testErrorObject(test, testEval(test, "fun() { var inner = 1; inner }(); inner"), "identifier not found: inner")
+}
+
+func TestAssignment(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var a = 1; a = 2; a", 2},
+		{"var a = 1; var b = 1; a = b = 5; a + b", 10},
+		{"var a = 1; fun() { a = a + 1 }(); a", 2},
+		{"var a = 1; fun(a) { a = 10 }(0); a", 1},
+		{"val a = 1; fun() { var a = 2; a = 3; a }()", 3},
+		{"var counter = fun() { var n = 0; fun() { n = n + 1; n } }; var c = counter(); c(); c(); c()", 3},
+		{"var counter = fun() { var n = 0; fun() { n = n + 1; n } }; var c = counter(); var d = counter(); c(); c(); d()", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestValBindings(test *testing.T) {
+	testIntegerObject(test, testEval(test, "val a = 5; val b = a * 2; b"), 10)
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"var f = fun() { x = 2 }; val x = 1; f()", "cannot assign to val binding x"},
+		{"val x = 1; var x = 2", "cannot redeclare val binding x"},
+		{"val x = 1; val x = 2", "cannot redeclare val binding x"},
+		{"y = 1", "identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestLetStatements(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x be 5; x", 5},
+		{"var a = 2; var b = 3; let total be a + b; total", 5},
+		{"let area be w * h being w = 3, h = 4; area", 12},
+		{"let r be area(2, 5) being area(w, h) = w * h; r", 10},
+		{"let y be x * 2 being x = 3, z = x + 1; y", 6},
+		{"let y be b being a = 2, b = a * 10; y", 20},
+		{"var w = 100; let area be w * h being w = 3, h = 4; w", 100},
+		{"let n be fact(5) being fact(k) = if (k < 2) { 1 } else { k * fact(k - 1) }; n", 120},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestLetStatementErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"let area be w * h being w = 3, h = 4; h", "identifier not found: h"},
+		{"var f = fun() { x = 2 }; let x be 1; f()", "cannot assign to val binding x"},
+		{"let x be 1; let x be 2", "cannot redeclare val binding x"},
+		{"let x be y being y = true + 1", "type mismatch: BOOLEAN + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestWordOperators(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true and true", true},
+		{"true and false", false},
+		{"false or true", true},
+		{"false or false", false},
+		{"not true", false},
+		{"not 1 == 2", true},
+		{"1 equal 1", true},
+		{"1 equal 2", false},
+		{"1 < 2 and 2 < 3", true},
+		{"1 and 2", 2},
+		{"0 or 2", 0},
+		{"false or 7", 7},
+		{"false and undefined", false},
+		{"true or undefined()", true},
+		{"var n = 0; var bump = fun() { n = n + 1; true }; false and bump(); true or bump(); n", 0},
+		{"var n = 0; var bump = fun() { n = n + 1; true }; true and bump(); false or bump(); n", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(test, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(test, evaluated, expected)
+		}
+	}
+}
+
+func TestModuloAndPower(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"10 % 3", 10 % 3},
+		{"-7 % 3", -7 % 3},
+		{"7 % -3", 7 % -3},
+		{"2 + 10 % 4 * 2", 6},
+		{"2 ^ 10", 1024},
+		{"2 ^ 3 ^ 2", 512},
+		{"(2 ^ 3) ^ 2", 64},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"(-2) ^ 3", -8},
+		{"3 * 2 ^ 2", 12},
+		{"5 ^ 0", 1},
+		{"2 ^ -1", 0.5},
+		{"2 ^ -2 ^ 1", 0.25},
+		{"(2 ^ -1) * (2 ^ -1)", 0.25},
+		{"(2 ^ -1) ^ (2 ^ -1)", 0.7071067811865476},
+		{"(3 ^ -1) % (4 ^ -1)", 0.08333333333333331},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(test, evaluated, int64(expected))
+		case float64:
+			testFloatObject(test, evaluated, expected)
+		}
+	}
+}
+
+func TestBigIntegers(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"2 ^ 200", "1606938044258990275541962092341162602522202993782792835301376"},
+		{"2 ^ 63", "9223372036854775808"},
+		{"2 ^ 62", 4611686018427387904},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"3037000500 * 3037000500", "9223372037000250000"},
+		{"-9223372036854775808 / -1", "9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"99999999999999999999", "99999999999999999999"},
+		{"99999999999999999999 - 99999999999999999998", 1},
+		{"2 ^ 64 / 2 ^ 60", 16},
+		{"2 ^ 64 % 10", 6},
+		{"-(9223372036854775808)", math.MinInt64},
+		{"(2 ^ 100) * (2 ^ 100) == 2 ^ 200", true},
+		{"2 ^ 64 > 2 ^ 63", true},
+		{"2 ^ 64 < 1", false},
+		{"2 ^ 64 != 2 ^ 64 + 0", false},
+		{"2 ^ 64 + 0.5", 18446744073709551616.5},
+		{"(2 ^ 64) ^ -1", 1 / 18446744073709551616.0},
+		{"type(2 ^ 100)", "INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(test, evaluated, int64(expected))
+		case float64:
+			testFloatObject(test, evaluated, expected)
+		case bool:
+			testBooleanObject(test, evaluated, expected)
+		case string:
+			if strings.HasPrefix(tt.input, "type") {
+				if str, ok := evaluated.(*object.String); !ok || str.Value != expected {
+					test.Errorf("%s: wrong type. got=%s", tt.input, evaluated.Inspect())
+				}
+				continue
+			}
+
+			big, ok := evaluated.(*object.BigInteger)
+			if !ok {
+				test.Errorf("%s: object is not BigInteger. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if big.Value.String() != expected {
+				test.Errorf("%s: wrong value. got=%s, want=%s", tt.input, big.Value, expected)
+			}
+		}
+	}
+}
+
+func TestBigIntegerErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"2 ^ 64 / 0", "division by zero: 18446744073709551616 / 0"},
+		{"2 ^ 64 % 0", "modulo by zero: 18446744073709551616 % 0"},
+		{"2 ^ 64 ^ 2 ^ 64", "exponent too large: 64 ^ 18446744073709551616"},
+		{"2 ^ 64 + true", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestDivisionByZero(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1 / 0", "division by zero: 1 / 0"},
+		{"1 % 0", "modulo by zero: 1 % 0"},
+		{"(2 ^ -1) % (2 ^ -1 - 2 ^ -1)", "modulo by zero: 0.5 % 0.0"},
+		{"(2 ^ -1) / (2 ^ -1 - 2 ^ -1)", "division by zero: 0.5 / 0.0"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func testFloatObject(test *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		test.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		test.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestEvalFloatExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 + 1", 1.5},
+		{"3 * 1.5", 4.5},
+		{"7 / 2.0", 3.5},
+		{"7 / 2", 3},
+		{"7.5 % 2", 1.5},
+		{"2.0 ^ 3", 8.0},
+		{"4 ^ 0.5", 2.0},
+		{"1e3 + 1", 1001.0},
+		{"1 < 1.5", true},
+		{"2.5 > 3", false},
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"0.1 + 0.2 == 0.3", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(test, evaluated, int64(expected))
+		case float64:
+			testFloatObject(test, evaluated, expected)
+		case bool:
+			testBooleanObject(test, evaluated, expected)
+		}
+	}
+}
+
+func TestStringExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello World!"`, "Hello World!"},
+		{`"Hello" + " " + "World!"`, "Hello World!"},
+		{`var name = "Clint"; "Hi, " + name`, "Hi, Clint"},
+		{`"tab\there"`, "tab\there"},
+		{`"a" == "a"`, true},
+		{`"a" == "b"`, false},
+		{`"a" != "b"`, true},
+		{`"a" + "b" equal "ab"`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				test.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				test.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+			}
+		case bool:
+			testBooleanObject(test, evaluated, expected)
+		}
+	}
+
+	testErrorObject(test, testEval(test, `"Hello" - "World"`), "unknown operator: STRING - STRING")
+}
+
+func TestStringInterpolation(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`var name = "Clint"; "Hello, ${name}!"`, "Hello, Clint!"},
+		{`"${1 + 2} is three"`, "3 is three"},
+		{`"${1.5} ${true} ${!true}"`, "1.5 true false"},
+		{`var f = fun(x) { x * 2 }; "${f(21)}"`, "42"},
+		{`var who = "world"; "say ${"hi ${who}"}"`, "say hi world"},
+		{`"${fun() { return }()}"`, "null"},
+		{`"cost: $5 \${x}"`, "cost: $5 ${x}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			test.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if str.Value != tt.expected {
+			test.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+
+	testErrorObject(test, testEval(test, `"${missing}"`), "identifier not found: missing")
+}
+
+func TestArrayLiterals(test *testing.T) {
+	evaluated := testEval(test, "[1, 2 * 2, 3 + 3]")
+
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		test.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		test.Fatalf("array has wrong number of elements. got=%d", len(result.Elements))
+	}
+
+	testIntegerObject(test, result.Elements[0], 1)
+	testIntegerObject(test, result.Elements[1], 4)
+	testIntegerObject(test, result.Elements[2], 6)
+
+	if result.Inspect() != "[1, 4, 6]" {
+		test.Errorf("wrong Inspect(). got=%q", result.Inspect())
+	}
+}
+
+func TestArrayIndexExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][2]", 3},
+		{"var i = 0; [1][i]", 1},
+		{"[1, 2, 3][1 + 1]", 3},
+		{"var xs = [1, 2, 3]; xs[0] + xs[1] + xs[2]", 6},
+		{"[1, 2, 3][-1]", 3},
+		{"[1, 2, 3][-3]", 1},
+		{"[[1, 2], [3, 4]][1][0]", 3},
+		{"[fun(x) { x * 2 }][0](21)", 42},
+		{"len([1, 2, 3])", 3},
+		{"len([])", 0},
+		{`len("café")`, 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), int64(tt.expected.(int)))
+	}
+}
+
+func TestArraySliceExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2, 3, 4][1:3]", "[2, 3]"},
+		{"[1, 2, 3, 4][:2]", "[1, 2]"},
+		{"[1, 2, 3, 4][2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:]", "[1, 2, 3, 4]"},
+		{"[1, 2, 3, 4][-2:]", "[3, 4]"},
+		{"[1, 2, 3, 4][:-1]", "[1, 2, 3]"},
+		{"[1, 2, 3, 4][2:2]", "[]"},
+		{"[1, 2, 3, 4][0:4]", "[1, 2, 3, 4]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			test.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestArrayErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"[1, 2, 3][3]", "index out of range: 3 (length 3)"},
+		{"[1, 2, 3][-4]", "index out of range: -4 (length 3)"},
+		{"[][0]", "index out of range: 0 (length 0)"},
+		{"[1][2 ^ 64]", "index out of range: 18446744073709551616 (length 1)"},
+		{`[1]["a"]`, "index operator not supported: ARRAY[STRING]"},
+		{"1[0]", "index operator not supported: INTEGER[INTEGER]"},
+		{"[1, 2][0:3]", "slice bound out of range: 3 (length 2)"},
+		{"[1, 2][-3:]", "slice bound out of range: -3 (length 2)"},
+		{"[1, 2][2:1]", "invalid slice bounds: 2 > 1"},
+		{`[1, 2]["a":]`, "slice bound must be INTEGER, got STRING"},
+		{"1[0:1]", "slice operator not supported: INTEGER"},
+		{"[1, foo]", "identifier not found: foo"},
+		{"len(1)", "argument to `len` not supported, got INTEGER"},
+		{"len([], [])", "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestHashLiterals(test *testing.T) {
+	input := `var two = "two";
+	{
+		"one": 10 - 9,
+		two: 1 + 1,
+		"thr" + "ee": 6 / 2,
+		4: 4,
+		true: 5,
+		false: 6,
+		2 ^ 64: 7
+	}`
+
+	evaluated := testEval(test, input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		test.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{object.TRUE, 5},
+		{object.FALSE, 6},
+		{testEval(test, "2 ^ 64").(*object.BigInteger), 7},
+	}
+
+	if len(result.Pairs) != len(expected) {
+		test.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for i, tt := range expected {
+		if result.Keys[i] != tt.key.HashKey() {
+			test.Errorf("key %d out of insertion order. got=%s", i, result.Pairs[result.Keys[i]].Key.Inspect())
+		}
+
+		pair, ok := result.Get(tt.key)
+		if !ok {
+			test.Errorf("no pair for given key in Pairs")
+			continue
+		}
+		testIntegerObject(test, pair.Value, tt.value)
+	}
+
+	if result.Inspect() != `{"one": 1, "two": 2, "three": 3, 4: 4, true: 5, false: 6, 18446744073709551616: 7}` {
+		test.Errorf("wrong Inspect(). got=%q", result.Inspect())
+	}
+}
+
+func TestHashIndexExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"foo": 5}["foo"]`, 5},
+		{`{"foo": 5}["bar"]`, nil},
+		{`var key = "foo"; {"foo": 5}[key]`, 5},
+		{`{}["foo"]`, nil},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`{false: 5}[false]`, 5},
+		{`{2 ^ 70: 1}[2 ^ 70]`, 1},
+		{`len({1: 2, 3: 4})`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(test, evaluated, int64(integer))
+		} else {
+			testNullObject(test, evaluated)
+		}
+	}
+}
+
+func TestIndexAssignment(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var xs = [1, 2, 3]; xs[0] = 10; xs", "[10, 2, 3]"},
+		{"var xs = [1, 2, 3]; xs[-1] = 30; xs", "[1, 2, 30]"},
+		{"val xs = [1, 2]; xs[1] = 5; xs", "[1, 5]"},
+		{`var h = {"a": 1}; h["b"] = 2; h["a"] = 3; h`, `{"a": 3, "b": 2}`},
+		{`var h = {}; h[1] = h[2] = "x"; h`, `{2: "x", 1: "x"}`},
+		{`var grid = [[0, 0], [0, 0]]; grid[1][0] = 7; grid`, "[[0, 0], [7, 0]]"},
+		{`var h = {"a": 1}; keys(h)`, `["a"]`},
+		{`var h = {"b": 1, "a": 2}; h["c"] = 3; keys(h)`, `["b", "a", "c"]`},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{"var xs = [1]; xs[0] = 2", "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			test.Errorf("%s: expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`{"name": "Monkey"}[fun(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1]: 2}`, "unusable as hash key: ARRAY"},
+		{`{1.5: 2}`, "unusable as hash key: FLOAT"},
+		{`{1: foo}`, "identifier not found: foo"},
+		{`var h = {}; h[[1]] = 1`, "unusable as hash key: ARRAY"},
+		{`var xs = [1]; xs[1] = 2`, "index out of range: 1 (length 1)"},
+		{`var xs = [1]; xs["a"] = 2`, "index operator not supported: ARRAY[STRING]"},
+		{`var s = "abc"; s[0] = "x"`, "index assignment not supported: STRING"},
+		{`keys([1])`, "argument to `keys` must be HASH, got ARRAY"},
+		{`values(1)`, "argument to `values` must be HASH, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestModules(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"module M { val x = 5; }; M::x", 5},
+		{"module Geometry { val area = fun(w, h) { w * h }; }; Geometry::area(2, 3)", 6},
+		{"module M { var _secret = 4; val get = fun() { _secret }; }; M::get()", 4},
+		{"val y = 2; module M { val x = y * 10; }; M::x", 20},
+		{"module A { module B { val c = 7; }; }; A::B::c", 7},
+		{"module M { var n = 1; val bump = fun() { n = n + 1 }; }; M::bump(); M::bump(); M::n", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(test, "module M { val x = 1; }; M")
+	module, ok := evaluated.(*object.Module)
+	if !ok {
+		test.Fatalf("object is not Module. got=%T (%+v)", evaluated, evaluated)
+	}
+	if module.Inspect() != "module M" {
+		test.Errorf("wrong inspect. got=%q", module.Inspect())
+	}
+
+	// Module members do not leak into the enclosing scope.
+	testErrorObject(test, testEval(test, "module M { val x = 1; }; x"), "identifier not found: x")
+}
+
+func TestModuleErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"module M { val _x = 1; }; M::_x", "_x is private to module M"},
+		{"module M { }; M::y", "module M has no member y"},
+		{"val n = 1; n::y", "n is not a module: INTEGER"},
+		{"val M = 1; module M { }", "cannot redeclare val binding M"},
+		{"module M { val x = y; }", "identifier not found: y"},
+		{"module M { return 1; }", "return outside function in module M"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+// writeModules writes each source to its file name in a new temporary
+// directory and makes that directory the import search path.
+func writeModules(test *testing.T, files map[string]string) string {
+	dir := test.TempDir()
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	saved := SearchPath
+	SearchPath = []string{dir}
+	test.Cleanup(func() { SearchPath = saved })
+
+	return dir
+}
+
+func TestImport(test *testing.T) {
+	dir := writeModules(test, map[string]string{
+		"geometry.clint": "val area = fun(w, h) { w * h }; val _unit = 1;",
+		"shapes.clint":   `import "./geometry"; val square = fun(s) { geometry::area(s, s) };`,
+	})
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`import "geometry"; geometry::area(2, 3)`, 6},
+		{`import "geometry.clint"; geometry::area(4, 5)`, 20},
+		{`import Geo from "geometry"; Geo::area(1, 7)`, 7},
+		{`import Geo from "` + filepath.Join(dir, "geometry.clint") + `"; Geo::area(2, 2)`, 4},
+		{`import "shapes"; shapes::square(3)`, 9},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(test, `import A from "geometry"; import B from "`+filepath.Join(dir, "geometry")+`"; [A, B]`)
+	if evaluated.Inspect() != "[module A, module B]" {
+		test.Errorf("aliases of one file not named after their bindings. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestImportEvaluatesOnce(test *testing.T) {
+	writeModules(test, map[string]string{
+		"counter.clint": "var count = 0; val next = fun() { count = count + 1 };",
+	})
+
+	evaluated := testEval(test, `
+import "counter";
+counter::next();
+import C from "counter";
+C::next();
+counter::count`)
+	testIntegerObject(test, evaluated, 2)
+}
+
+func TestImportErrors(test *testing.T) {
+	dir := writeModules(test, map[string]string{
+		"a.clint":       `import "b";`,
+		"b.clint":       `import "c";`,
+		"c.clint":       `import "a";`,
+		"self.clint":    `import "self";`,
+		"bad.clint":     "val = 1",
+		"failing.clint": "val x = 1 + true",
+		"private.clint": "val _hidden = 1",
+		"returns.clint": "val x = 1; return x; val y = 2;",
+		"outer.clint":   `import "failing";`,
+		"nested.clint":  "val x = 1;\nimport \"missing\";",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`import "missing"`, `1:1: cannot find module "missing" in ` + dir},
+		{`val x = 1; import "missing"`, `1:12: cannot find module "missing" in ` + dir},
+		{`import "nested"`, "1:1: " + path("nested.clint") + `:2:1: cannot find module "missing" in ` + dir},
+		{`import "a"`, "1:1: " + path("a.clint") + ":1:1: " + path("b.clint") + ":1:1: " + path("c.clint") + ":1:1: import cycle: " + path("a.clint") + " -> " + path("b.clint") + " -> " + path("c.clint") + " -> " + path("a.clint")},
+		{`import "self"`, "1:1: " + path("self.clint") + ":1:1: import cycle: " + path("self.clint") + " -> " + path("self.clint")},
+		{`import "bad"`, "1:1: cannot import " + path("bad.clint") + ": " + path("bad.clint") + ":1:5: expected next token to be IDENT, got = instead"},
+		{`import "failing"`, "1:1: in " + path("failing.clint") + ": type mismatch: INTEGER + BOOLEAN"},
+		{`import "returns"`, "1:1: in " + path("returns.clint") + ": return outside function in module returns"},
+		{`import "outer"`, "1:1: " + path("outer.clint") + ":1:1: in " + path("failing.clint") + ": type mismatch: INTEGER + BOOLEAN"},
+		{`import "private"; private::_hidden`, "_hidden is private to module private"},
+		{`val private = 1; import "private"`, "cannot redeclare val binding private"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestClasses(test *testing.T) {
+	point := `
+class Point {
+	fun init(x, y) {
+		self.x = x;
+		self.y = y;
+	}
+
+	fun norm() { self.x * self.x + self.y * self.y }
+
+	fun move(dx, dy) {
+		self.x = self.x + dx;
+		self.y = self.y + dy;
+		self
+	}
+}
+`
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{point + "Point(3, 4).norm()", 25},
+		{point + "val p = Point(1, 2); p.x + p.y", 3},
+		{point + "val p = Point(1, 2); p.move(1, 1).move(1, 1); p.norm()", 25},
+		{point + "val p = Point(1, 2); val norm = p.norm; p.x = 3; norm()", 13},
+		{point + "val p = Point(1, 2); val q = Point(5, 6); p.x = 10; q.x", 5},
+		{point + "val p = Point(1, 2); p.z = 7; p.z", 7},
+		{"class Counter { fun bump() { self.n = self.n + 1 } fun init() { self.n = 0 } }; " +
+			"val c = Counter(); c.bump(); c.bump()", 2},
+		{"val base = 10; class A { fun get() { base + 1 } }; A().get()", 11},
+		{"class A { fun init() { return 5; } fun one() { 1 } }; A().one()", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(test, point+"Point(1, 2)")
+	instance, ok := evaluated.(*object.Instance)
+	if !ok {
+		test.Fatalf("object is not Instance. got=%T (%+v)", evaluated, evaluated)
+	}
+	if instance.Inspect() != "Point{x: 1, y: 2}" {
+		test.Errorf("wrong inspect. got=%q", instance.Inspect())
+	}
+
+	evaluated = testEval(test, "class A {}; var a = A(); a.me = a; a")
+	if evaluated.Inspect() != "A{me: A{...}}" {
+		test.Errorf("wrong inspect of self-referential instance. got=%q", evaluated.Inspect())
+	}
+
+	evaluated = testEval(test, "var xs = [1]; xs[0] = xs; xs")
+	if evaluated.Inspect() != "[[...]]" {
+		test.Errorf("wrong inspect of self-referential array. got=%q", evaluated.Inspect())
+	}
+
+	evaluated = testEval(test, point+"Point")
+	if class, ok := evaluated.(*object.Class); !ok || class.Inspect() != "class Point" {
+		test.Errorf("object is not class Point. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestClassErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"class P { fun init(x) { self.x = x } }; P()", "wrong number of arguments: want=1, got=0"},
+		{"class P { }; P(1)", "wrong number of arguments: want=0, got=1"},
+		{"class P { fun f(a) { a } }; P().f()", "wrong number of arguments: want=1, got=0"},
+		{"class P { }; P().x", "undefined member x of P"},
+		{"class P { fun init() { self.x = y } }; P()", "identifier not found: y"},
+		{"class P { fun f() { self = 1 } }; P().f()", "cannot assign to val binding self"},
+		{"val n = 1; n.x", "member access not supported: INTEGER.x"},
+		{"var n = 1; n.x = 2", "member assignment not supported: INTEGER.x"},
+		{"class P { }; P.x", "member access not supported: CLASS.x"},
+		{"val P = 1; class P { }", "cannot redeclare val binding P"},
+		{"fun() { self }()", "identifier not found: self"},
+		{"class P { fun f() { 1 } }; val p = P(); p.f = 2", "cannot assign to method f of P"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestInheritance(test *testing.T) {
+	shapes := `
+class Shape {
+	fun init(name) { self.name = name }
+	fun area() { 0 }
+	fun sides() { 0 }
+	fun scaled(k) { self.area() * k }
+}
+
+class Rect < Shape {
+	fun init(w, h) {
+		super.init("rect");
+		self.w = w;
+		self.h = h;
+	}
+	fun area() { self.w * self.h }
+	fun sides() { 4 }
+}
+
+class Square < Rect {
+	fun init(s) { super.init(s, s) }
+	fun sides() { super.sides() + 0 }
+}
+`
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{shapes + "Rect(2, 3).area()", 6},
+		{shapes + "Square(3).area()", 9},
+		{shapes + "Square(3).sides()", 4},
+		{shapes + "Square(2).scaled(10)", 40},
+		{shapes + "Shape(\"blob\").scaled(10)", 0},
+		{shapes + "val s = Square(2); val area = fun() { s.area() }; area()", 4},
+		{"class A { fun f() { 1 } }; class B < A { fun g() { super.f } }; B().g()()", 1},
+		{"module M { class A { fun f() { 7 } } }; class B < M::A { }; B().f()", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(test, testEval(test, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(test, shapes+"Square(2).name")
+	str, ok := evaluated.(*object.String)
+	if !ok || str.Value != "rect" {
+		test.Errorf("inherited init did not set name. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestIsA(test *testing.T) {
+	classes := "class A { }; class B < A { }; class C { }; "
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{classes + "is_a?(A(), A)", true},
+		{classes + "is_a?(B(), A)", true},
+		{classes + "is_a?(B(), B)", true},
+		{classes + "is_a?(A(), B)", false},
+		{classes + "is_a?(C(), A)", false},
+		{classes + "is_a?(1, A)", false},
+		{classes + "is_a?(A, A)", false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(test, testEval(test, tt.input), tt.expected)
+	}
+}
+
+func TestInheritanceErrors(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"val S = 1; class C < S { }", "superclass of C must be CLASS, got INTEGER"},
+		{"class C < S { }", "identifier not found: S"},
+		{"class A { }; class B < A { fun f() { super.g() } }; B().f()", "undefined method g in superclass A"},
+		{"class A { fun f() { super.f() } }; A().f()", "super used in a class without a superclass"},
+		{"class A { fun f() { super.f() } }; class B < A { }; B().f()", "super used in a class without a superclass"},
+		{"fun() { super.f() }()", "super used outside a method"},
+		{"class A { }; is_a?(A(), 1)", "second argument to `is_a?` must be CLASS, got INTEGER"},
+		{"class A { }; is_a?(A())", "wrong number of arguments. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
+
+func TestEmptyValuesAreNull(test *testing.T) {
+	nulls := []string{
+		"fun() {}()",
+		"fun() { var x = 1 }()",
+		"fun() { val x = 1 }()",
+		"fun() { let x be 1 }()",
+		"if (true) { }",
+		"class A { fun f() {} }; A().f()",
+	}
+
+	for _, input := range nulls {
+		testNullObject(test, testEval(test, input))
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[fun(){}()]", "[null]"},
+		{`{"a": fun(){}()}`, `{"a": null}`},
+		{`"${fun(){}()}"`, "null"},
+		{"not fun(){}()", "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(test, tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			test.Errorf("%s: expected=%s, got=%v", tt.input, tt.expected, evaluated)
+		}
+	}
+
+	errors := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"fun() {}() + 1", "type mismatch: NULL + INTEGER"},
+		{"1 + if (true) { }", "type mismatch: INTEGER + NULL"},
+		{"class A { fun f() {} }; A().f() + 1", "type mismatch: NULL + INTEGER"},
+		{"len(fun(){}())", "argument to `len` not supported, got NULL"},
+	}
+
+	for _, tt := range errors {
+		testErrorObject(test, testEval(test, tt.input), tt.expectedMessage)
+	}
+}
@@ -0,0 +1,168 @@
+package evaluator
+
+import (
+	"clint/ast"
+	"clint/lexer"
+	"clint/object"
+	"clint/parser"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extension is the file extension of Clint source files. It may be left
+// off import paths.
+const Extension = ".clint"
+
+// SearchPath lists the directories bare import paths such as "geometry"
+// are looked up in, in order. It defaults to the working directory, the
+// project root, followed by the directories in $CLINT_PATH.
+var SearchPath = defaultSearchPath()
+
+var (
+	// modules caches every file imported so far by absolute path, so each
+	// file is evaluated once per process.
+	modules = map[string]*object.Module{}
+
+	// importing is the chain of files currently being imported, outermost
+	// first, used to detect cycles.
+	importing []string
+)
+
+func defaultSearchPath() []string {
+	path := []string{"."}
+	for _, dir := range filepath.SplitList(os.Getenv("CLINT_PATH")) {
+		if dir != "" {
+			path = append(path, dir)
+		}
+	}
+	return path
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	name := node.Name()
+	if env.IsImmutable(name) {
+		return newError("cannot redeclare val binding %s", name)
+	}
+
+	filename, ok := resolveImport(node.Path.Value, node.Token.Pos.Filename)
+	if !ok {
+		return importError(node, "cannot find module %q in %s", node.Path.Value, strings.Join(SearchPath, string(filepath.ListSeparator)))
+	}
+
+	module := importFile(node, filename)
+	if isError(module) {
+		return module
+	}
+
+	env.SetImmutable(name, module)
+	return nil
+}
+
+// importError is newError for a failure to load the file node imports,
+// prefixed with the position of node so that it can be found.
+func importError(node *ast.ImportStatement, format string, a ...interface{}) *object.Error {
+	return newError("%s: %s", node.Pos(), fmt.Sprintf(format, a...))
+}
+
+// resolveImport finds the file an import path refers to. Paths starting
+// with ./ or ../ are relative to the directory of the importing file,
+// other relative paths are looked up in SearchPath.
+func resolveImport(path, importer string) (string, bool) {
+	if filepath.Ext(path) == "" {
+		path += Extension
+	}
+
+	var candidates []string
+	switch {
+	case filepath.IsAbs(path):
+		candidates = []string{path}
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		candidates = []string{filepath.Join(filepath.Dir(importer), path)}
+	default:
+		for _, dir := range SearchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// importFile evaluates filename as the body of the module node imports,
+// or reuses the scope of an earlier import of the same file. Each import
+// gets a module named after its own binding.
+func importFile(node *ast.ImportStatement, filename string) object.Object {
+	name := node.Name()
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return importError(node, "cannot import %s: %s", filename, err)
+	}
+
+	if module, ok := modules[abs]; ok {
+		if module.Name != name {
+			module = &object.Module{Name: name, Env: module.Env}
+		}
+		return module
+	}
+
+	for i, loading := range importing {
+		if loading == abs {
+			chain := append(append([]string{}, importing[i:]...), abs)
+			for j := range chain {
+				chain[j] = displayPath(chain[j])
+			}
+			return importError(node, "import cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	source, err := os.ReadFile(abs)
+	if err != nil {
+		return importError(node, "cannot import %s: %s", displayPath(abs), err)
+	}
+
+	p := parser.New(lexer.NewWithFilename(displayPath(abs), string(source)))
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return importError(node, "cannot import %s: %s", displayPath(abs), errors[0])
+	}
+
+	importing = append(importing, abs)
+	defer func() { importing = importing[:len(importing)-1] }()
+
+	// Errors raised by the body are prefixed with the file they come from,
+	// unless they already point into it, and with the import of the file,
+	// so that the chain of imports leading to them can be followed.
+	moduleEnv := object.NewEnvironment()
+	if result := evalModuleBody(name, program.Statements, moduleEnv); isError(result) {
+		message := result.(*object.Error).Message
+		if strings.HasPrefix(message, displayPath(abs)+":") {
+			return importError(node, "%s", message)
+		}
+		return importError(node, "in %s: %s", displayPath(abs), message)
+	}
+
+	module := &object.Module{Name: name, Env: moduleEnv}
+	modules[abs] = module
+	return module
+}
+
+// displayPath shortens an absolute path to one relative to the working
+// directory where that does not climb out of it.
+func displayPath(abs string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return abs
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return abs
+	}
+	return rel
+}
@@ -1,39 +1,170 @@
 package lexer
 
-import "clint/token"
+import (
+	"clint/diagnostic"
+	"clint/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mode controls optional lexer behaviour.
+type Mode uint
+
+const (
+	// ScanComments makes NextToken return comments as token.COMMENT
+	// instead of skipping them, for formatters and doc generators.
+	ScanComments Mode = 1 << iota
+)
 
 // Lexer ...
 type Lexer struct {
+	filename     string
+	mode         Mode
 	input        string
-	position     int
-	readPosition int
-	ch           byte
+	position     int  // byte offset of ch
+	readPosition int  // byte offset just past ch
+	ch           rune // current character, or 0 at the end of input
+	line         int  // line of ch
+	column       int  // column of ch, counted in characters
+	diagnostics  []diagnostic.Diagnostic
+
+	// templates holds, for every string interpolation currently open, the
+	// depth of braces opened inside it, so that the '}' closing the
+	// interpolation can be told apart from one closing a block.
+	templates []int
 }
 
 // New ..
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename returns a Lexer whose token positions name filename.
+func NewWithFilename(filename, input string) *Lexer {
+	return NewWithMode(filename, input, 0)
+}
+
+// NewWithMode returns a Lexer for filename using the given mode.
+func NewWithMode(filename, input string, mode Mode) *Lexer {
+	l := &Lexer{filename: filename, input: input, mode: mode, line: 1}
 	l.readChar()
+	if l.ch == '\uFEFF' {
+		// A byte order mark at the start of the input is not part of it.
+		l.readChar()
+	}
+	l.column = 1
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+
+	if l.position < len(l.input) {
+		l.column++
+	}
+
+	l.position = l.readPosition
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
+		return
 	}
 
-	l.position = l.readPosition
-	l.readPosition++
+	ch, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	l.ch = ch
+	l.readPosition += width
+
+	if ch == utf8.RuneError && width == 1 {
+		l.errorf(diagnostic.InvalidUTF8, l.charSpan(), "invalid UTF-8 encoding")
+	}
+}
+
+// isInvalid reports whether the current character is a byte that is not
+// valid UTF-8, which readChar has already reported.
+func (l *Lexer) isInvalid() bool {
+	return l.ch == utf8.RuneError && l.readPosition-l.position == 1
+}
+
+// pos returns the position of the current character.
+func (l *Lexer) pos() token.Position {
+	offset := l.position
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+
+	return token.Position{Filename: l.filename, Offset: offset, Line: l.line, Column: l.column}
+}
+
+// nextPos returns the position just past the current character.
+func (l *Lexer) nextPos() token.Position {
+	pos := l.pos()
+	if l.position < len(l.input) {
+		pos.Offset = l.readPosition
+		pos.Column++
+	}
+	return pos
+}
+
+// spanFrom returns the span from start through the current character.
+func (l *Lexer) spanFrom(start token.Position) diagnostic.Span {
+	return diagnostic.Span{Start: start, End: l.nextPos()}
+}
+
+// charSpan returns the span of the current character.
+func (l *Lexer) charSpan() diagnostic.Span {
+	return diagnostic.Span{Start: l.pos(), End: l.nextPos()}
+}
+
+// Diagnostics returns the problems found so far, such as illegal
+// characters and malformed string literals.
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic { return l.diagnostics }
+
+// Errors returns the diagnostics formatted as single-line messages.
+func (l *Lexer) Errors() []string {
+	errors := []string{}
+	for _, d := range l.diagnostics {
+		errors = append(errors, d.Error())
+	}
+	return errors
+}
+
+func (l *Lexer) errorf(code string, span diagnostic.Span, format string, a ...interface{}) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Errorf(code, span, format, a...))
 }
 
 // NextToken ...
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWhitespace()
 
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		pos := l.pos()
+		text := l.readComment()
+
+		if l.mode&ScanComments != 0 {
+			return token.Token{Type: token.COMMENT, Literal: text, Pos: pos, End: l.pos()}
+		}
+		l.skipWhitespace()
+	}
+
+	pos := l.pos()
+	tok := l.scanToken()
+
+	tok.Pos = pos
+	tok.End = l.pos()
+	if tok.Type == token.EOF {
+		tok.End = pos
+	}
+
+	return tok
+}
+
+func (l *Lexer) scanToken() token.Token {
+	var tok token.Token
+
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -85,10 +216,33 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LPAREN, l.ch)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
+	case '[':
+		tok = newToken(token.LBRACKET, l.ch)
+	case ']':
+		tok = newToken(token.RBRACKET, l.ch)
 	case '{':
+		if len(l.templates) > 0 {
+			l.templates[len(l.templates)-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.templates); n > 0 {
+			if l.templates[n-1] == 0 {
+				l.templates = l.templates[:n-1]
+				tok = l.readString(token.TMPLMIDDLE, token.TMPLTAIL)
+				break
+			}
+			l.templates[n-1]--
+		}
 		tok = newToken(token.RBRACE, l.ch)
+	case '.':
+		if isDigit(l.peekChar()) {
+			tok.Type, tok.Literal = l.readNumber()
+			return tok
+		}
+		tok = newToken(token.DOT, l.ch)
+	case '"':
+		tok = l.readString(token.TMPLHEAD, token.STR)
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -98,10 +252,12 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
 			return tok
+		} else if l.isInvalid() {
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:l.readPosition]}
 		} else {
+			l.errorf(diagnostic.IllegalCharacter, l.charSpan(), "illegal character %q", l.ch)
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
@@ -110,35 +266,325 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+// readComment reads a // comment up to the end of the line, or a /* */
+// comment, which may nest, and returns its text including the delimiters.
+func (l *Lexer) readComment() string {
+	start := l.position
+	startPos := l.pos()
+
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		return l.input[start:l.position]
+	}
+
+	l.readChar()
+	l.readChar()
+
+	for depth := 1; depth > 0; l.readChar() {
+		switch {
+		case l.ch == 0:
+			l.errorf(diagnostic.UnterminatedComment, diagnostic.Span{Start: startPos, End: l.pos()}, "unterminated block comment")
+			return l.input[start:l.position]
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+	}
+
+	return l.input[start:l.position]
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// readIdentifier reads a name made of letters, digits and underscores,
+// with an optional trailing ? or ! in the style of Ruby predicates
+// (empty?) and mutators (sort!).
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
+		l.readChar()
+	}
+
+	if l.isIdentifierSuffix() {
 		l.readChar()
 	}
+
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// isIdentifierSuffix reports whether the current ? or ! ends the
+// identifier before it. It does not when it starts a != or ?= operator,
+// so a!=b is a != b while a!==b is a! == b, nor when an identifier
+// follows, so a?b is a ? b.
+func (l *Lexer) isIdentifierSuffix() bool {
+	if l.ch != '?' && l.ch != '!' {
+		return false
+	}
+
+	next := l.peekChar()
+	if next == '=' {
+		return l.peekCharAt(1) == '='
+	}
+	return !isLetter(next) && !unicode.IsDigit(next)
+}
+
+// numberBases maps the letter of a 0x, 0o or 0b prefix to its base.
+var numberBases = map[rune]struct {
+	base int
+	name string
+}{
+	'x': {16, "hexadecimal"}, 'X': {16, "hexadecimal"},
+	'o': {8, "octal"}, 'O': {8, "octal"},
+	'b': {2, "binary"}, 'B': {2, "binary"},
+}
+
+// readNumber reads an integer or a float literal. A float has a fraction
+// (1.5, .5) and/or an exponent (6.02e23, 1e-3); a '.' or 'e' that is not
+// followed by digits ends the literal instead. Integers may also be
+// written in hexadecimal, octal or binary, and digits of any literal may
+// be separated by underscores (1_000_000). A malformed literal is
+// reported and returned as ILLEGAL.
+func (l *Lexer) readNumber() (token.TokenType, string) {
+	start := l.pos()
+	position := l.position
+
+	if _, ok := numberBases[l.peekChar()]; ok && l.ch == '0' {
+		return l.readPrefixedNumber(start)
+	}
+
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+
+	if (l.ch == 'e' || l.ch == 'E') && l.isExponentAhead() {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits()
+	}
+
+	literal := l.input[position:l.position]
+	if !l.checkSeparators(start, literal, isDigit) {
+		return token.ILLEGAL, literal
+	}
+	return tokenType, literal
+}
+
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// readPrefixedNumber reads an integer literal starting with 0x, 0o or 0b.
+// Letters and digits running on are read as part of the literal so that
+// they are reported as invalid digits.
+func (l *Lexer) readPrefixedNumber(start token.Position) (token.TokenType, string) {
 	position := l.position
-	for isDigit(l.ch) {
+	prefix := numberBases[l.peekChar()]
+	l.readChar()
+	l.readChar()
+
+	digits := l.position
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	literal := l.input[position:l.position]
+
+	if digits == l.position {
+		l.errorf(diagnostic.MalformedNumber, l.spanFrom(start), "%s literal has no digits", prefix.name)
+		return token.ILLEGAL, literal
+	}
+
+	isBaseDigit := func(ch rune) bool { return digitValue(ch) < prefix.base }
+
+	for i, ch := range l.input[digits:l.position] {
+		if ch != '_' && !isBaseDigit(ch) {
+			pos := numberPos(start, digits-position+i)
+			l.errorf(diagnostic.MalformedNumber, diagnostic.Span{Start: pos, End: numberPos(pos, 1)},
+				"invalid digit %q in %s literal", ch, prefix.name)
+			return token.ILLEGAL, literal
+		}
+	}
+
+	if !l.checkSeparators(numberPos(start, 2), literal[2:], isBaseDigit) {
+		return token.ILLEGAL, literal
+	}
+	return token.INT, literal
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' ||
-		'A' <= ch && ch <= 'Z' ||
-		ch == '_' ||
-		ch == '!' ||
-		ch == '?'
+// checkSeparators reports an underscore in the literal starting at start
+// that does not sit between two digits, as in 1__0 or 1_.
+func (l *Lexer) checkSeparators(start token.Position, literal string, isDigit func(rune) bool) bool {
+	for i, ch := range literal {
+		if ch != '_' {
+			continue
+		}
+
+		if i == 0 || i == len(literal)-1 || !isDigit(rune(literal[i-1])) || !isDigit(rune(literal[i+1])) {
+			pos := numberPos(start, i)
+			l.errorf(diagnostic.MalformedNumber, diagnostic.Span{Start: pos, End: numberPos(pos, 1)},
+				"'_' must separate successive digits")
+			return false
+		}
+	}
+	return true
+}
+
+// numberPos returns the position n bytes after pos within a number
+// literal, which never spans lines.
+func numberPos(pos token.Position, n int) token.Position {
+	pos.Offset += n
+	pos.Column += n
+	return pos
 }
 
-func isDigit(ch byte) bool {
+// isExponentAhead reports whether the 'e' at the current position starts
+// an exponent, that is whether it is followed by digits with an optional
+// sign.
+func (l *Lexer) isExponentAhead() bool {
+	next := l.readPosition
+	if next < len(l.input) && (l.input[next] == '+' || l.input[next] == '-') {
+		next++
+	}
+	return next < len(l.input) && isDigit(rune(l.input[next]))
+}
+
+// readString reads the text of a string literal, or of the rest of one
+// after an interpolation, resolving escape sequences. The text runs up to
+// the closing quote, giving a closed token, or up to a "${", giving an
+// open token and entering the interpolation. A string left open at the end
+// of the line or input is reported and returned as an ILLEGAL token.
+func (l *Lexer) readString(open, closed token.TokenType) token.Token {
+	start := l.position
+	startPos := l.pos()
+	var out strings.Builder
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			return token.Token{Type: closed, Literal: out.String()}
+		case 0, '\n':
+			l.errorf(diagnostic.UnterminatedString, diagnostic.Span{Start: startPos, End: l.pos()}, "unterminated string literal")
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[start:l.position]}
+		case '\\':
+			l.readEscape(&out)
+		case '$':
+			if l.peekChar() == '{' {
+				l.readChar()
+				l.templates = append(l.templates, 0)
+				return token.Token{Type: open, Literal: out.String()}
+			}
+			out.WriteRune(l.ch)
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape resolves the escape sequence starting at the current '\'.
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.pos()
+
+	// Leave a backslash at the end of the line to be reported as an
+	// unterminated string.
+	if next := l.peekChar(); next == 0 || next == '\n' {
+		return
+	}
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '\\':
+		out.WriteByte('\\')
+	case '$':
+		out.WriteByte('$')
+	case 'u':
+		l.readUnicodeEscape(start, out)
+	default:
+		l.errorf(diagnostic.InvalidEscape, l.spanFrom(start), "unknown escape sequence \\%c", l.ch)
+		out.WriteRune(l.ch)
+	}
+}
+
+// readUnicodeEscape resolves \u{XXXX}, with one to six hex digits naming
+// a Unicode code point.
+func (l *Lexer) readUnicodeEscape(start token.Position, out *strings.Builder) {
+	if l.peekChar() != '{' {
+		l.errorf(diagnostic.InvalidEscape, l.spanFrom(start), "invalid unicode escape: expected {")
+		return
+	}
+	l.readChar()
+
+	digits := l.readPosition
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	hex := l.input[digits:l.readPosition]
+
+	if l.peekChar() != '}' {
+		l.errorf(diagnostic.InvalidEscape, l.spanFrom(start), "invalid unicode escape: expected }")
+		return
+	}
+	l.readChar()
+
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) > 6 || !utf8.ValidRune(rune(code)) {
+		l.errorf(diagnostic.InvalidEscape, l.spanFrom(start), "invalid unicode escape \\u{%s}", hex)
+		return
+	}
+
+	out.WriteRune(rune(code))
+}
+
+// digitValue returns the value of ch as a hexadecimal digit, or 16 if it
+// is not one.
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	case 'A' <= ch && ch <= 'F':
+		return int(ch-'A') + 10
+	}
+	return 16
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// isDigit reports whether ch is an ASCII digit; numeric literals are
+// written with those only.
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
@@ -148,10 +594,21 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(0)
+}
+
+// peekCharAt returns the character n characters after the next one.
+func (l *Lexer) peekCharAt(n int) rune {
+	offset := l.readPosition
+	for ; n > 0 && offset < len(l.input); n-- {
+		_, width := utf8.DecodeRuneInString(l.input[offset:])
+		offset += width
+	}
+
+	if offset >= len(l.input) {
 		return 0
-	} else {
-		return l.input[l.readPosition]
 	}
+	ch, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return ch
 }
@@ -12,7 +12,7 @@ func TestNextToken(test *testing.T) {
 		x + y;
 	};
 	var result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 
 	if (5 < 10) {
@@ -119,3 +119,439 @@ func TestNextToken(test *testing.T) {
 		}
 	}
 }
+
+func TestNumberLiterals(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{"42", []token.Token{{Type: token.INT, Literal: "42"}}},
+		{"3.14", []token.Token{{Type: token.FLOAT, Literal: "3.14"}}},
+		{".5", []token.Token{{Type: token.FLOAT, Literal: ".5"}}},
+		{"6.02e23", []token.Token{{Type: token.FLOAT, Literal: "6.02e23"}}},
+		{"1e-3", []token.Token{{Type: token.FLOAT, Literal: "1e-3"}}},
+		{"2E+10", []token.Token{{Type: token.FLOAT, Literal: "2E+10"}}},
+		{"1.5*2", []token.Token{
+			{Type: token.FLOAT, Literal: "1.5"},
+			{Type: token.MULT, Literal: "*"},
+			{Type: token.INT, Literal: "2"},
+		}},
+		{"1.", []token.Token{
+			{Type: token.INT, Literal: "1"},
+			{Type: token.DOT, Literal: "."},
+		}},
+		{"p.x", []token.Token{
+			{Type: token.IDENT, Literal: "p"},
+			{Type: token.DOT, Literal: "."},
+			{Type: token.IDENT, Literal: "x"},
+		}},
+		{"2e", []token.Token{
+			{Type: token.INT, Literal: "2"},
+			{Type: token.IDENT, Literal: "e"},
+		}},
+		{"3e+", []token.Token{
+			{Type: token.INT, Literal: "3"},
+			{Type: token.IDENT, Literal: "e"},
+			{Type: token.PLUS, Literal: "+"},
+		}},
+		{"0x1F", []token.Token{{Type: token.INT, Literal: "0x1F"}}},
+		{"0XdeAD_beef", []token.Token{{Type: token.INT, Literal: "0XdeAD_beef"}}},
+		{"0o755", []token.Token{{Type: token.INT, Literal: "0o755"}}},
+		{"0b1010_0101", []token.Token{{Type: token.INT, Literal: "0b1010_0101"}}},
+		{"1_000_000", []token.Token{{Type: token.INT, Literal: "1_000_000"}}},
+		{"1_000.000_1e1_0", []token.Token{{Type: token.FLOAT, Literal: "1_000.000_1e1_0"}}},
+		{"0x10-1", []token.Token{
+			{Type: token.INT, Literal: "0x10"},
+			{Type: token.MINUS, Literal: "-"},
+			{Type: token.INT, Literal: "1"},
+		}},
+		{"0b1)", []token.Token{
+			{Type: token.INT, Literal: "0b1"},
+			{Type: token.RPAREN, Literal: ")"},
+		}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		for i, expected := range tt.expected {
+			tok := l.NextToken()
+
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				test.Errorf("%q: tokens[%d] wrong. expected=%s(%q), got=%s(%q)",
+					tt.input, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			test.Errorf("%q: expected EOF, got=%s(%q)", tt.input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestStringLiterals(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"hello world"`, token.STR, "hello world"},
+		{`""`, token.STR, ""},
+		{`"a\nb\tc"`, token.STR, "a\nb\tc"},
+		{`"say \"hi\""`, token.STR, `say "hi"`},
+		{`"back\\slash"`, token.STR, `back\slash`},
+		{`"\u{48}\u{69}"`, token.STR, "Hi"},
+		{`"\u{1F600}"`, token.STR, "\U0001F600"},
+		{`"caf\u{e9}"`, token.STR, "café"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			test.Errorf("%s: wrong tokentype. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			test.Errorf("%s: wrong literal. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+
+		if len(l.Errors()) != 0 {
+			test.Errorf("%s: unexpected errors %q", tt.input, l.Errors())
+		}
+
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			test.Errorf("%s: expected EOF, got=%q", tt.input, tok.Type)
+		}
+	}
+}
+
+func TestStringLiteralErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`"abc`, "1:1: unterminated string literal"},
+		{"var s = \"abc\nx", "1:9: unterminated string literal"},
+		{"1;\n  \"abc\\", "2:3: unterminated string literal"},
+		{`"\q"`, `1:2: unknown escape sequence \q`},
+		{`"\u{110000}"`, `1:2: invalid unicode escape \u{110000}`},
+		{`"\u41"`, "1:2: invalid unicode escape: expected {"},
+		{`"\u{41"`, "1:2: invalid unicode escape: expected }"},
+		{"@", "1:1: illegal character '@'"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) == 0 {
+			test.Errorf("%q: expected error, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestMalformedNumbers(test *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"0x", "0x", "1:1: hexadecimal literal has no digits"},
+		{"0o;", "0o", "1:1: octal literal has no digits"},
+		{"0b2", "0b2", "1:3: invalid digit '2' in binary literal"},
+		{"0o18", "0o18", "1:4: invalid digit '8' in octal literal"},
+		{"0x1G", "0x1G", "1:4: invalid digit 'G' in hexadecimal literal"},
+		{"1__0", "1__0", "1:2: '_' must separate successive digits"},
+		{"x = 10_", "10_", "1:7: '_' must separate successive digits"},
+		{"0x_1", "0x_1", "1:3: '_' must separate successive digits"},
+		{"1_.5", "1_.5", "1:2: '_' must separate successive digits"},
+		{"1.5_e3", "1.5_e3", "1:4: '_' must separate successive digits"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		var illegal token.Token
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+			if tok.Type == token.ILLEGAL && illegal.Type == "" {
+				illegal = tok
+			}
+		}
+
+		if illegal.Literal != tt.expectedLiteral {
+			test.Errorf("%q: wrong ILLEGAL literal. expected=%q, got=%q", tt.input, tt.expectedLiteral, illegal.Literal)
+		}
+
+		errors := l.Errors()
+		if len(errors) == 0 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestStringInterpolation(test *testing.T) {
+	input := `"Hello, ${name}!" "${a + b}" "x${ {1}["k"] }y${"in${n}"}z" "\${raw}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TMPLHEAD, "Hello, "},
+		{token.IDENT, "name"},
+		{token.TMPLTAIL, "!"},
+		{token.TMPLHEAD, ""},
+		{token.IDENT, "a"},
+		{token.PLUS, "+"},
+		{token.IDENT, "b"},
+		{token.TMPLTAIL, ""},
+		{token.TMPLHEAD, "x"},
+		{token.LBRACE, "{"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STR, "k"},
+		{token.RBRACKET, "]"},
+		{token.TMPLMIDDLE, "y"},
+		{token.TMPLHEAD, "in"},
+		{token.IDENT, "n"},
+		{token.TMPLTAIL, ""},
+		{token.TMPLTAIL, "z"},
+		{token.STR, "${raw}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			test.Fatalf("tests[%d] - wrong tokentype. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			test.Fatalf("tests[%d] - wrong literal. expected=%q, got=%q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTokenPositions(test *testing.T) {
+	input := "var x = 10;\n  x == \"a\\tb\"\n\n}"
+
+	tests := []struct {
+		expectedType token.TokenType
+		pos          token.Position
+		end          token.Position
+	}{
+		{token.VAR, token.Position{Filename: "main.clint", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.clint", Offset: 3, Line: 1, Column: 4}},
+		{token.IDENT, token.Position{Filename: "main.clint", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "main.clint", Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Filename: "main.clint", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "main.clint", Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Filename: "main.clint", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "main.clint", Offset: 10, Line: 1, Column: 11}},
+		{token.SEMI, token.Position{Filename: "main.clint", Offset: 10, Line: 1, Column: 11}, token.Position{Filename: "main.clint", Offset: 11, Line: 1, Column: 12}},
+		{token.IDENT, token.Position{Filename: "main.clint", Offset: 14, Line: 2, Column: 3}, token.Position{Filename: "main.clint", Offset: 15, Line: 2, Column: 4}},
+		{token.EQ, token.Position{Filename: "main.clint", Offset: 16, Line: 2, Column: 5}, token.Position{Filename: "main.clint", Offset: 18, Line: 2, Column: 7}},
+		{token.STR, token.Position{Filename: "main.clint", Offset: 19, Line: 2, Column: 8}, token.Position{Filename: "main.clint", Offset: 25, Line: 2, Column: 14}},
+		{token.RBRACE, token.Position{Filename: "main.clint", Offset: 27, Line: 4, Column: 1}, token.Position{Filename: "main.clint", Offset: 28, Line: 4, Column: 2}},
+		{token.EOF, token.Position{Filename: "main.clint", Offset: 28, Line: 4, Column: 2}, token.Position{Filename: "main.clint", Offset: 28, Line: 4, Column: 2}},
+	}
+
+	l := NewWithFilename("main.clint", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			test.Fatalf("tests[%d] - wrong tokentype. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tt.pos {
+			test.Errorf("tests[%d] - wrong pos. expected=%s@%d, got=%s@%d", i, tt.pos, tt.pos.Offset, tok.Pos, tok.Pos.Offset)
+		}
+
+		if tok.End != tt.end {
+			test.Errorf("tests[%d] - wrong end. expected=%s@%d, got=%s@%d", i, tt.end, tt.end.Offset, tok.End, tok.End.Offset)
+		}
+	}
+
+	if s := tests[5].pos.String(); s != "main.clint:2:3" {
+		test.Errorf("Position.String() wrong. got=%q", s)
+	}
+}
+
+func TestComments(test *testing.T) {
+	input := "a // line\n/* block /* nested */ still */ b /**/ / c // end"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.COMMENT, "// line"},
+		{token.COMMENT, "/* block /* nested */ still */"},
+		{token.IDENT, "b"},
+		{token.COMMENT, "/**/"},
+		{token.DIV, "/"},
+		{token.IDENT, "c"},
+		{token.COMMENT, "// end"},
+		{token.EOF, ""},
+	}
+
+	l := NewWithMode("", input, ScanComments)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			test.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	// By default comments are skipped.
+	l = New(input)
+	for _, expected := range []token.TokenType{token.IDENT, token.IDENT, token.DIV, token.IDENT, token.EOF} {
+		if tok := l.NextToken(); tok.Type != expected {
+			test.Fatalf("wrong token without ScanComments. expected=%s, got=%s", expected, tok.Type)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		test.Errorf("unexpected errors %q", l.Errors())
+	}
+}
+
+func TestUnterminatedComment(test *testing.T) {
+	l := New("1 /* a /* b */ c")
+	l.NextToken()
+
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		test.Errorf("expected EOF after unterminated comment. got=%s", tok.Type)
+	}
+
+	errors := l.Errors()
+	if len(errors) != 1 || errors[0] != "1:3: unterminated block comment" {
+		test.Errorf("wrong errors. got=%q", errors)
+	}
+}
+
+func TestUnicode(test *testing.T) {
+	input := "\uFEFFvar café = \"😀\"; π2 + naïve\n日本 x"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		line, column    int
+		offset          int
+	}{
+		{token.VAR, "var", 1, 1, 3},
+		{token.IDENT, "café", 1, 5, 7},
+		{token.ASSIGN, "=", 1, 10, 13},
+		{token.STR, "😀", 1, 12, 15},
+		{token.SEMI, ";", 1, 15, 21},
+		{token.IDENT, "π2", 1, 17, 23},
+		{token.PLUS, "+", 1, 20, 27},
+		{token.IDENT, "naïve", 1, 22, 29},
+		{token.IDENT, "日本", 2, 1, 36},
+		{token.IDENT, "x", 2, 4, 43},
+		{token.EOF, "", 2, 5, 44},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			test.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.line || tok.Pos.Column != tt.column || tok.Pos.Offset != tt.offset {
+			test.Errorf("tests[%d] - wrong pos for %q. expected=%d:%d@%d, got=%s@%d", i, tok.Literal, tt.line, tt.column, tt.offset, tok.Pos, tok.Pos.Offset)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		test.Errorf("unexpected errors %q", l.Errors())
+	}
+}
+
+func TestInvalidUTF8(test *testing.T) {
+	l := New("a \xff b \"c\xfe\"")
+
+	expected := []token.TokenType{token.IDENT, token.ILLEGAL, token.IDENT, token.STR, token.EOF}
+	for i, tt := range expected {
+		if tok := l.NextToken(); tok.Type != tt {
+			test.Fatalf("tests[%d] - wrong tokentype. expected=%q, got=%q", i, tt, tok.Type)
+		}
+	}
+
+	errors := l.Errors()
+	expectedErrors := []string{"1:3: invalid UTF-8 encoding", "1:9: invalid UTF-8 encoding"}
+	if len(errors) != len(expectedErrors) {
+		test.Fatalf("wrong errors. expected=%q, got=%q", expectedErrors, errors)
+	}
+	for i, err := range errors {
+		if err != expectedErrors[i] {
+			test.Errorf("wrong error. expected=%q, got=%q", expectedErrors[i], err)
+		}
+	}
+}
+
+func TestIdentifierSuffixes(test *testing.T) {
+	type tok struct {
+		typ     token.TokenType
+		literal string
+	}
+
+	tests := []struct {
+		input    string
+		expected []tok
+	}{
+		{"empty?", []tok{{token.IDENT, "empty?"}}},
+		{"sort!", []tok{{token.IDENT, "sort!"}}},
+		{"is_a?(x)", []tok{{token.IDENT, "is_a?"}, {token.LPAREN, "("}, {token.IDENT, "x"}, {token.RPAREN, ")"}}},
+		{"!x", []tok{{token.TELL, "!"}, {token.IDENT, "x"}}},
+		{"!empty?", []tok{{token.TELL, "!"}, {token.IDENT, "empty?"}}},
+		{"!!x", []tok{{token.TELL, "!"}, {token.TELL, "!"}, {token.IDENT, "x"}}},
+		{"a!=b", []tok{{token.IDENT, "a"}, {token.NOTEQ, "!="}, {token.IDENT, "b"}}},
+		{"a != b", []tok{{token.IDENT, "a"}, {token.NOTEQ, "!="}, {token.IDENT, "b"}}},
+		{"a!==b", []tok{{token.IDENT, "a!"}, {token.EQ, "=="}, {token.IDENT, "b"}}},
+		{"a?==b", []tok{{token.IDENT, "a?"}, {token.EQ, "=="}, {token.IDENT, "b"}}},
+		{"a?=b", []tok{{token.IDENT, "a"}, {token.ASK, "?"}, {token.ASSIGN, "="}, {token.IDENT, "b"}}},
+		{"a?b", []tok{{token.IDENT, "a"}, {token.ASK, "?"}, {token.IDENT, "b"}}},
+		{"a!b", []tok{{token.IDENT, "a"}, {token.TELL, "!"}, {token.IDENT, "b"}}},
+		{"a??", []tok{{token.IDENT, "a?"}, {token.ASK, "?"}}},
+		{"a!!", []tok{{token.IDENT, "a!"}, {token.TELL, "!"}}},
+		{"a?!", []tok{{token.IDENT, "a?"}, {token.TELL, "!"}}},
+		{"a? ? b", []tok{{token.IDENT, "a?"}, {token.ASK, "?"}, {token.IDENT, "b"}}},
+		{"f(a!, b?)", []tok{{token.IDENT, "f"}, {token.LPAREN, "("}, {token.IDENT, "a!"}, {token.COMMA, ","}, {token.IDENT, "b?"}, {token.RPAREN, ")"}}},
+		{"x2? y_3!", []tok{{token.IDENT, "x2?"}, {token.IDENT, "y_3!"}}},
+		{"?x", []tok{{token.ASK, "?"}, {token.IDENT, "x"}}},
+		{"? !", []tok{{token.ASK, "?"}, {token.TELL, "!"}}},
+		{"café?", []tok{{token.IDENT, "café?"}}},
+		{"true!", []tok{{token.IDENT, "true!"}}},
+		{"not!x", []tok{{token.NOT, "not"}, {token.TELL, "!"}, {token.IDENT, "x"}}},
+		{"1!=2", []tok{{token.INT, "1"}, {token.NOTEQ, "!="}, {token.INT, "2"}}},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+
+		for i, expected := range tt.expected {
+			got := l.NextToken()
+			if got.Type != expected.typ || got.Literal != expected.literal {
+				test.Errorf("%q: token %d wrong. expected=%s %q, got=%s %q", tt.input, i, expected.typ, expected.literal, got.Type, got.Literal)
+				break
+			}
+		}
+
+		if got := l.NextToken(); got.Type != token.EOF {
+			test.Errorf("%q: expected EOF, got=%s %q", tt.input, got.Type, got.Literal)
+		}
+	}
+}
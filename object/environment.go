@@ -0,0 +1,66 @@
+package object
+
+// Environment holds the bindings visible to the code being evaluated.
+// Lookups that miss fall through to the enclosing environment, which is
+// how functions see the scope they were defined in.
+type Environment struct {
+	store     map[string]Object
+	immutable map[string]bool
+	outer     *Environment
+}
+
+// NewEnvironment ...
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, immutable: make(map[string]bool), outer: nil}
+}
+
+// NewEnclosedEnvironment creates a scope nested inside outer. Bindings set
+// in it shadow, but never overwrite, those of outer.
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get ...
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// GetLocal looks name up in this scope only.
+func (e *Environment) GetLocal(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	return obj, ok
+}
+
+// Set binds name in this scope only.
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
+// SetImmutable binds name in this scope as a val binding.
+func (e *Environment) SetImmutable(name string, val Object) Object {
+	e.immutable[name] = true
+	return e.Set(name, val)
+}
+
+// IsImmutable reports whether name is a val binding of this scope.
+func (e *Environment) IsImmutable(name string) bool {
+	return e.immutable[name]
+}
+
+// Resolve returns the innermost scope, starting at e, that binds name.
+func (e *Environment) Resolve(name string) (*Environment, bool) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,67 @@
+package object
+
+import "testing"
+
+func TestEnclosedEnvironment(test *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+	outer.Set("y", &Integer{Value: 2})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("x", &Integer{Value: 10})
+	inner.Set("z", &Integer{Value: 30})
+
+	tests := []struct {
+		env      *Environment
+		name     string
+		expected int64
+		found    bool
+	}{
+		{inner, "x", 10, true},
+		{inner, "y", 2, true},
+		{inner, "z", 30, true},
+		{outer, "x", 1, true},
+		{outer, "z", 0, false},
+	}
+
+	for _, tt := range tests {
+		obj, ok := tt.env.Get(tt.name)
+		if ok != tt.found {
+			test.Errorf("Get(%q) found=%t, want=%t", tt.name, ok, tt.found)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if obj.(*Integer).Value != tt.expected {
+			test.Errorf("Get(%q) wrong value. expected=%d, got=%s", tt.name, tt.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestResolveImmutableBinding(test *testing.T) {
+	outer := NewEnvironment()
+	outer.SetImmutable("pi", &Integer{Value: 3})
+
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("r", &Integer{Value: 2})
+
+	scope, ok := inner.Resolve("pi")
+	if !ok || scope != outer {
+		test.Fatalf("Resolve(pi) did not return the outer scope")
+	}
+
+	if !scope.IsImmutable("pi") {
+		test.Errorf("pi is not immutable")
+	}
+
+	if inner.IsImmutable("r") {
+		test.Errorf("r is immutable")
+	}
+
+	if _, ok := inner.Resolve("missing"); ok {
+		test.Errorf("Resolve(missing) found a scope")
+	}
+}
@@ -0,0 +1,429 @@
+package object
+
+import (
+	"bytes"
+	"clint/ast"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ObjectType ...
+type ObjectType string
+
+const (
+	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
+	STRING_OBJ       = "STRING"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	NULL_OBJ         = "NULL"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	BUILTIN_OBJ      = "BUILTIN"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	MODULE_OBJ       = "MODULE"
+	CLASS_OBJ        = "CLASS"
+	INSTANCE_OBJ     = "INSTANCE"
+	BOUND_METHOD_OBJ = "BOUND_METHOD"
+)
+
+// TRUE, FALSE and NULL are the only instances of their values, so
+// runtime values can be compared by identity.
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
+// Object ...
+type Object interface {
+	Type() ObjectType
+	Inspect() string
+}
+
+// Integer ...
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+// BigInteger is an integer that does not fit in an int64. It has the same
+// type as Integer: the two representations are an implementation detail.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (i *BigInteger) Type() ObjectType { return INTEGER_OBJ }
+func (i *BigInteger) Inspect() string  { return i.Value.String() }
+
+// NewInteger returns value as an Integer if it fits in an int64, and as a
+// BigInteger otherwise.
+func NewInteger(value *big.Int) Object {
+	if value.IsInt64() {
+		return &Integer{Value: value.Int64()}
+	}
+	return &BigInteger{Value: value}
+}
+
+// Float ...
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	out := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(out, ".eIN") {
+		out += ".0"
+	}
+	return out
+}
+
+// String ...
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
+// Boolean ...
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+
+// NativeBoolToBooleanObject returns the shared TRUE or FALSE instance.
+func NativeBoolToBooleanObject(input bool) *Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+// Array ...
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string  { return a.inspect(map[Object]bool{}) }
+
+func (a *Array) inspect(printing map[Object]bool) string {
+	if printing[a] {
+		return "[...]"
+	}
+	printing[a] = true
+	defer delete(printing, a)
+
+	elements := []string{}
+	for _, el := range a.Elements {
+		elements = append(elements, inspectElement(el, printing))
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// container is implemented by the objects that hold other objects, and
+// so can end up holding themselves.
+type container interface {
+	// inspect is Inspect for an object that may contain those being
+	// printed, which are abbreviated rather than printed forever.
+	inspect(printing map[Object]bool) string
+}
+
+// inspectElement is Inspect for a value held by another object. Strings
+// are quoted, so that "1" and 1, or "a, b" and "a", "b", can be told
+// apart.
+func inspectElement(obj Object, printing map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *String:
+		return ast.Quote(obj.Value)
+	case container:
+		return obj.inspect(printing)
+	default:
+		return obj.Inspect()
+	}
+}
+
+// HashKey identifies a hash key. Value is the canonical form of the key
+// within its Type, so two keys have the same HashKey exactly when they
+// are equal.
+type HashKey struct {
+	Type  ObjectType
+	Value string
+}
+
+// Hashable is implemented by the objects that can be used as hash keys.
+type Hashable interface {
+	Object
+	HashKey() HashKey
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: strconv.FormatInt(i.Value, 10)}
+}
+
+// HashKey of a BigInteger shares its form with Integer: both are decimal,
+// and a BigInteger never holds a value that fits an Integer.
+func (i *BigInteger) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: i.Value.String()}
+}
+
+func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: s.Value}
+}
+
+func (b *Boolean) HashKey() HashKey {
+	return HashKey{Type: b.Type(), Value: strconv.FormatBool(b.Value)}
+}
+
+// HashPair keeps the original key next to its value, so that keys can be
+// listed and inspected.
+type HashPair struct {
+	Key   Hashable
+	Value Object
+}
+
+// Hash maps keys to values and remembers the order in which keys were
+// first inserted; Inspect and iteration follow that order.
+type Hash struct {
+	Pairs map[HashKey]HashPair
+	Keys  []HashKey
+}
+
+// NewHash returns an empty Hash.
+func NewHash() *Hash {
+	return &Hash{Pairs: map[HashKey]HashPair{}}
+}
+
+// Get returns the pair stored under key.
+func (h *Hash) Get(key Hashable) (HashPair, bool) {
+	pair, ok := h.Pairs[key.HashKey()]
+	return pair, ok
+}
+
+// Set stores value under key. Replacing the value of an existing key
+// keeps its position.
+func (h *Hash) Set(key Hashable, value Object) {
+	hashKey := key.HashKey()
+	if _, ok := h.Pairs[hashKey]; !ok {
+		h.Keys = append(h.Keys, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+func (h *Hash) Type() ObjectType { return HASH_OBJ }
+func (h *Hash) Inspect() string  { return h.inspect(map[Object]bool{}) }
+
+func (h *Hash) inspect(printing map[Object]bool) string {
+	if printing[h] {
+		return "{...}"
+	}
+	printing[h] = true
+	defer delete(printing, h)
+
+	pairs := []string{}
+	for _, key := range h.Keys {
+		pair := h.Pairs[key]
+		pairs = append(pairs, inspectElement(pair.Key, printing)+": "+inspectElement(pair.Value, printing))
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// Null ...
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Inspect() string  { return "null" }
+
+// IsTruthy reports whether obj counts as true in a condition. null and
+// false are the only falsy values; everything else, including 0 and the
+// empty string, is truthy.
+func IsTruthy(obj Object) bool {
+	switch obj {
+	case TRUE:
+		return true
+	case nil, NULL, FALSE:
+		return false
+	}
+
+	// Values built outside the runtime need not be the singletons.
+	switch obj := obj.(type) {
+	case *Boolean:
+		return obj.Value
+	case *Null:
+		return false
+	default:
+		return true
+	}
+}
+
+// ReturnValue wraps the value of a return statement while it unwinds
+// through the enclosing blocks.
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// Error ...
+type Error struct {
+	Message string
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+// Function is a closure: it keeps the environment it was defined in.
+type Function struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fun")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(f.Body.String())
+	out.WriteString("\n}")
+
+	return out.String()
+}
+
+// Class is the value of a class declaration. Calling it creates an
+// Instance and runs its init method, if any, on it.
+type Class struct {
+	Name       string
+	Superclass *Class // nil if the class does not inherit
+	Methods    map[string]*Function
+}
+
+func (c *Class) Type() ObjectType { return CLASS_OBJ }
+func (c *Class) Inspect() string  { return "class " + c.Name }
+
+// FindMethod looks up the method called name in the class and then in
+// its superclasses, nearest first.
+func (c *Class) FindMethod(name string) (*Function, bool) {
+	for class := c; class != nil; class = class.Superclass {
+		if method, ok := class.Methods[name]; ok {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// IsSubclassOf reports whether c is other or inherits from it.
+func (c *Class) IsSubclassOf(other *Class) bool {
+	for class := c; class != nil; class = class.Superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+// Instance is an object created by calling a Class. Its fields are
+// remembered in the order they were first assigned.
+type Instance struct {
+	Class  *Class
+	Fields map[string]Object
+	Names  []string
+}
+
+// NewInstance returns an instance of class without fields.
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, Fields: map[string]Object{}}
+}
+
+// Get returns the field called name.
+func (i *Instance) Get(name string) (Object, bool) {
+	value, ok := i.Fields[name]
+	return value, ok
+}
+
+// Set assigns the field called name, adding it if it does not exist.
+func (i *Instance) Set(name string, value Object) {
+	if _, ok := i.Fields[name]; !ok {
+		i.Names = append(i.Names, name)
+	}
+	i.Fields[name] = value
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+func (i *Instance) Inspect() string  { return i.inspect(map[Object]bool{}) }
+
+func (i *Instance) inspect(printing map[Object]bool) string {
+	if printing[i] {
+		return i.Class.Name + "{...}"
+	}
+	printing[i] = true
+	defer delete(printing, i)
+
+	fields := []string{}
+	for _, name := range i.Names {
+		fields = append(fields, name+": "+inspectElement(i.Fields[name], printing))
+	}
+
+	return i.Class.Name + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// BoundMethod is a method looked up on an instance. Calling it runs the
+// method with self bound to Receiver.
+type BoundMethod struct {
+	Receiver *Instance
+	Name     string
+	Method   *Function
+}
+
+func (bm *BoundMethod) Type() ObjectType { return BOUND_METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	return "method " + bm.Receiver.Class.Name + "." + bm.Name
+}
+
+// Module is the value of a module declaration. Its members are the
+// bindings of Env, the scope its body was evaluated in.
+type Module struct {
+	Name string
+	Env  *Environment
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return "module " + m.Name }
+
+// IsPrivateName reports whether a module member named name is private to
+// the module, which is the case when it starts with an underscore.
+func IsPrivateName(name string) bool {
+	return strings.HasPrefix(name, "_")
+}
+
+// BuiltinFunction ...
+type BuiltinFunction func(args ...Object) Object
+
+// Builtin wraps a Go function so it can be called from Clint code.
+type Builtin struct {
+	Name string
+	Fn   BuiltinFunction
+}
+
+func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
+func (b *Builtin) Inspect() string  { return "builtin " + b.Name }
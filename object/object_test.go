@@ -0,0 +1,209 @@
+package object
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestInspect(test *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected string
+	}{
+		{&Integer{Value: -42}, "-42"},
+		{&Float{Value: 3}, "3.0"},
+		{&Float{Value: 0.25}, "0.25"},
+		{&Float{Value: 6.02e23}, "6.02e+23"},
+		{&String{Value: "hello"}, "hello"},
+		{TRUE, "true"},
+		{FALSE, "false"},
+		{NULL, "null"},
+		{&Error{Message: "boom"}, "ERROR: boom"},
+		{&ReturnValue{Value: &Integer{Value: 1}}, "1"},
+		{&Builtin{Name: "len"}, "builtin len"},
+		{&Module{Name: "Geometry"}, "module Geometry"},
+		{&Class{Name: "Point"}, "class Point"},
+		{&Array{Elements: []Object{&String{Value: "a, b"}, &Integer{Value: 1}}}, `["a, b", 1]`},
+		{&Array{Elements: []Object{&String{Value: "say \"hi\"\n"}}}, `["say \"hi\"\n"]`},
+	}
+
+	for _, tt := range tests {
+		if got := tt.obj.Inspect(); got != tt.expected {
+			test.Errorf("%T.Inspect() wrong. expected=%q, got=%q", tt.obj, tt.expected, got)
+		}
+	}
+}
+
+func TestIsTruthy(test *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected bool
+	}{
+		{TRUE, true},
+		{FALSE, false},
+		{NULL, false},
+		{&Integer{Value: 0}, true},
+		{&Float{Value: 0}, true},
+		{&String{Value: ""}, true},
+		{&Boolean{Value: false}, false},
+		{&Boolean{Value: true}, true},
+		{&Null{}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTruthy(tt.obj); got != tt.expected {
+			test.Errorf("IsTruthy(%#v) wrong. expected=%t, got=%t", tt.obj, tt.expected, got)
+		}
+	}
+}
+
+func TestNativeBoolToBooleanObject(test *testing.T) {
+	if NativeBoolToBooleanObject(true) != TRUE {
+		test.Errorf("true is not the TRUE singleton")
+	}
+
+	if NativeBoolToBooleanObject(false) != FALSE {
+		test.Errorf("false is not the FALSE singleton")
+	}
+}
+
+func TestNewInteger(test *testing.T) {
+	small := NewInteger(big.NewInt(42))
+	if integer, ok := small.(*Integer); !ok || integer.Value != 42 {
+		test.Errorf("NewInteger(42) wrong. got=%T (%+v)", small, small)
+	}
+
+	value, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	large := NewInteger(value)
+	if _, ok := large.(*BigInteger); !ok {
+		test.Fatalf("NewInteger(large) is not BigInteger. got=%T", large)
+	}
+
+	if large.Type() != INTEGER_OBJ || large.Inspect() != "123456789012345678901234567890" {
+		test.Errorf("wrong BigInteger. got=%s %s", large.Type(), large.Inspect())
+	}
+}
+
+func TestHashKey(test *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		test.Errorf("strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff.HashKey() {
+		test.Errorf("strings with different content have same hash keys")
+	}
+
+	if (&Integer{Value: 1}).HashKey() == TRUE.HashKey() {
+		test.Errorf("1 and true have the same hash key")
+	}
+
+	big1, _ := new(big.Int).SetString("18446744073709551616", 10)
+	big2, _ := new(big.Int).SetString("-18446744073709551616", 10)
+	if (&BigInteger{Value: big1}).HashKey() == (&BigInteger{Value: big2}).HashKey() {
+		test.Errorf("big integers of opposite sign have the same hash key")
+	}
+}
+
+func TestHashKeysDoNotCollide(test *testing.T) {
+	// 2^64 and 5952119183343170476 shared a key when keys were digests.
+	large := NewInteger(new(big.Int).Lsh(big.NewInt(1), 64)).(Hashable)
+	small := &Integer{Value: 5952119183343170476}
+
+	hash := NewHash()
+	hash.Set(large, &String{Value: "big"})
+	hash.Set(small, &String{Value: "small"})
+
+	if len(hash.Keys) != 2 {
+		test.Fatalf("wrong number of keys. got=%d", len(hash.Keys))
+	}
+
+	pair, ok := hash.Get(large)
+	if !ok || pair.Value.(*String).Value != "big" {
+		test.Errorf("Get(2^64) wrong. got=%v, %t", pair.Value, ok)
+	}
+
+	if (&Integer{Value: 10}).HashKey() == (&String{Value: "10"}).HashKey() {
+		test.Errorf("10 and \"10\" have the same hash key")
+	}
+}
+
+func TestHashOrder(test *testing.T) {
+	hash := NewHash()
+	hash.Set(&String{Value: "b"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 2}, &Integer{Value: 2})
+	hash.Set(TRUE, &Integer{Value: 3})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 4})
+
+	if hash.Inspect() != `{"b": 4, 2: 2, true: 3}` {
+		test.Errorf("wrong Inspect(). got=%q", hash.Inspect())
+	}
+
+	pair, ok := hash.Get(&Integer{Value: 2})
+	if !ok || pair.Value.Inspect() != "2" {
+		test.Errorf("Get(2) wrong. got=%v, %t", pair.Value, ok)
+	}
+
+	quoted := NewHash()
+	quoted.Set(&String{Value: "1"}, &String{Value: "a"})
+	quoted.Set(&Integer{Value: 1}, &String{Value: "b"})
+	if quoted.Inspect() != `{"1": "a", 1: "b"}` {
+		test.Errorf("wrong Inspect(). got=%q", quoted.Inspect())
+	}
+
+	if _, ok := hash.Get(&String{Value: "missing"}); ok {
+		test.Errorf("Get(missing) found a pair")
+	}
+}
+
+func TestInstanceFieldOrder(test *testing.T) {
+	point := NewInstance(&Class{Name: "Point"})
+	point.Set("y", &Integer{Value: 2})
+	point.Set("x", &Integer{Value: 1})
+	point.Set("y", &Integer{Value: 3})
+
+	if got := point.Inspect(); got != "Point{y: 3, x: 1}" {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+
+	method := &BoundMethod{Receiver: point, Name: "norm"}
+	if got := method.Inspect(); got != "method Point.norm" {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+}
+
+func TestInspectCycles(test *testing.T) {
+	node := NewInstance(&Class{Name: "Node"})
+	child := NewInstance(&Class{Name: "Node"})
+	node.Set("child", child)
+	child.Set("parent", node)
+	node.Set("me", node)
+
+	if got := node.Inspect(); got != "Node{child: Node{parent: Node{...}}, me: Node{...}}" {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+
+	array := &Array{}
+	array.Elements = []Object{&Integer{Value: 1}, array}
+	if got := array.Inspect(); got != "[1, [...]]" {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+
+	hash := NewHash()
+	hash.Set(&String{Value: "self"}, hash)
+	hash.Set(&String{Value: "list"}, &Array{Elements: []Object{hash}})
+	if got := hash.Inspect(); got != `{"self": {...}, "list": [{...}]}` {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+
+	// An object held twice, but not inside itself, is printed in full.
+	shared := &Array{Elements: []Object{&Integer{Value: 1}}}
+	pair := &Array{Elements: []Object{shared, shared}}
+	if got := pair.Inspect(); got != "[[1], [1]]" {
+		test.Errorf("wrong inspect. got=%q", got)
+	}
+}
@@ -2,10 +2,13 @@ package parser
 
 import (
 	"clint/ast"
+	"clint/diagnostic"
 	"clint/lexer"
 	"clint/token"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
 type (
@@ -17,7 +20,11 @@ type (
 const (
 	_ int = iota
 	LOWEST
-	EQUALS      // ==
+	ASSIGN      // x = y
+	OR          // x or y
+	AND         // x and y
+	NOT         // not x
+	EQUALS      // == or equal
 	LESSGREATER // > or <
 	SUM         // +
 	MINUS       // -
@@ -25,7 +32,10 @@ const (
 	DIV         // /
 	MOD         // %
 	PREFIX      // -X or !X
+	POWER       // x ^ y
 	CALL        // myFun(x)
+	INDEX       // xs[i]
+	SCOPE       // Module::member
 )
 
 // Parser ...
@@ -34,7 +44,36 @@ type Parser struct {
 
 	currentToken token.Token
 	peekToken    token.Token
-	errors       []string
+	diagnostics  []diagnostic.Diagnostic
+	lexerErrors  int
+
+	// Comments are only seen when the lexer runs in lexer.ScanComments
+	// mode. They are collected into groups, and a group directly above a
+	// token is kept as that token's doc comment.
+	comments   []*ast.CommentGroup
+	currentDoc *ast.CommentGroup
+	peekDoc    *ast.CommentGroup
+
+	// panicking is set by a syntax error and cleared once the parser has
+	// synchronized on the next statement, so that a single mistake is not
+	// reported again by every parse function that trips over it.
+	panicking bool
+
+	// braces counts the '{' read and not yet closed, so that recovery
+	// can skip to the end of the statement that was being parsed.
+	braces int
+
+	// scopes records, per function scope, the declaration of each name so
+	// that reassigning a val binding can be reported before evaluation.
+	scopes []map[string]declaration
+
+	// blockEnd is the '}' that closed the last block or class body. A
+	// statement ending in it needs no ';'.
+	blockEnd token.Token
+
+	// fields collects the self.field assignments in the body of the class
+	// being parsed, so that a field named like a method can be reported.
+	fields []*ast.Identifier
 
 	prefixParseFns  map[token.TokenType]prefixParseFn
 	infixParseFns   map[token.TokenType]infixParseFn
@@ -55,29 +94,47 @@ func (p *Parser) registerPostfix(tokenType token.TokenType, fn postfixParseFn) {
 
 // New ...
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l}
+	p.pushScope()
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STR, p.parseStringLiteral)
+	p.registerPrefix(token.TMPLHEAD, p.parseInterpolatedString)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TELL, p.parsePrefixExpression)
+	p.registerPrefix(token.NOT, p.parseNotExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUN, p.parseFunctionLiteral)
+	p.registerPrefix(token.SUPER, p.parseSuperExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.DIV, p.parseInfixExpression)
 	p.registerInfix(token.MULT, p.parseInfixExpression)
+	p.registerInfix(token.MOD, p.parseInfixExpression)
+	p.registerInfix(token.POW, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOTEQ, p.parseInfixExpression)
+	p.registerInfix(token.EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseLogicalExpression)
+	p.registerInfix(token.OR, p.parseLogicalExpression)
 	p.registerInfix(token.LTHEN, p.parseInfixExpression)
 	p.registerInfix(token.GTHEN, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.MODACCESSOR, p.parseScopedExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
 
 	// Read token two times, so currentToken & peekToken are both set
 	p.nextToken()
@@ -87,15 +144,24 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 var precedences = map[token.TokenType]int{
-	token.EQ:     EQUALS,
-	token.NOTEQ:  EQUALS,
-	token.LTHEN:  LESSGREATER,
-	token.GTHEN:  LESSGREATER,
-	token.LPAREN: CALL,
-	token.PLUS:   SUM,
-	token.MINUS:  SUM,
-	token.DIV:    MULT,
-	token.MULT:   MULT,
+	token.ASSIGN:      ASSIGN,
+	token.OR:          OR,
+	token.AND:         AND,
+	token.EQ:          EQUALS,
+	token.NOTEQ:       EQUALS,
+	token.EQUAL:       EQUALS,
+	token.LTHEN:       LESSGREATER,
+	token.GTHEN:       LESSGREATER,
+	token.LPAREN:      CALL,
+	token.LBRACKET:    INDEX,
+	token.MODACCESSOR: SCOPE,
+	token.DOT:         INDEX,
+	token.PLUS:        SUM,
+	token.MINUS:       SUM,
+	token.DIV:         MULT,
+	token.MULT:        MULT,
+	token.MOD:         MULT,
+	token.POW:         POWER,
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -112,17 +178,151 @@ func (p *Parser) currentPrecedence() int {
 	return LOWEST
 }
 
-// Errors ...
-func (p *Parser) Errors() []string { return p.errors }
+// Diagnostics returns the problems found by the lexer and the parser, in
+// source order of discovery.
+func (p *Parser) Diagnostics() []diagnostic.Diagnostic { return p.diagnostics }
+
+// Errors returns the diagnostics formatted as single-line messages.
+func (p *Parser) Errors() []string {
+	errors := []string{}
+	for _, d := range p.diagnostics {
+		errors = append(errors, d.Error())
+	}
+	return errors
+}
+
+// report records d, unless a diagnostic with the same code was already
+// reported at the same position.
+func (p *Parser) report(d diagnostic.Diagnostic) {
+	for _, seen := range p.diagnostics {
+		if seen.Code == d.Code && seen.Span.Start == d.Span.Start {
+			return
+		}
+	}
+	p.diagnostics = append(p.diagnostics, d)
+}
 
+// syntaxError records d and makes the parser panic until it synchronizes;
+// syntax errors found meanwhile are consequences of d and are dropped.
+// So is an error about an unexpected ILLEGAL token, which the lexer has
+// already reported.
+func (p *Parser) syntaxError(d diagnostic.Diagnostic) {
+	if p.panicking || p.peekTokenIs(token.ILLEGAL) {
+		p.panicking = true
+		return
+	}
+	p.report(d)
+	p.panicking = true
+}
+
+// errorf records an error covering span.
+func (p *Parser) errorf(code string, span diagnostic.Span, format string, a ...interface{}) {
+	p.report(diagnostic.Errorf(code, span, format, a...))
+}
+
+// peekError reports that the peek token is not t. When t closes a
+// delimiter, inserting it after the current token is suggested.
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.syntaxError(p.unexpectedPeek(t))
+}
+
+func (p *Parser) unexpectedPeek(t token.TokenType) diagnostic.Diagnostic {
+	d := diagnostic.Errorf(diagnostic.UnexpectedToken, diagnostic.TokenSpan(p.peekToken),
+		"expected next token to be %s, got %s instead", t, p.peekToken.Type)
+
+	if t == token.RPAREN || t == token.RBRACE || t == token.RBRACKET || t == token.SEMI {
+		d.Suggestion = &diagnostic.Suggestion{
+			Span:        diagnostic.Span{Start: p.currentToken.End, End: p.currentToken.End},
+			Replacement: string(t),
+			Message:     fmt.Sprintf("insert `%s`", t),
+		}
+	}
+
+	return d
+}
+
+// expectSemicolon consumes the ';' ending a statement. It may be left
+// out before the '}' closing a block, at the end of the input and after a
+// statement that itself ends in a block, such as an if or a class.
+func (p *Parser) expectSemicolon() {
+	switch {
+	case p.peekTokenIs(token.SEMI):
+		p.nextToken()
+	case p.peekTokenIs(token.RBRACE), p.peekTokenIs(token.EOF):
+	case p.currentTokenIs(token.RBRACE) && p.currentToken.Pos == p.blockEnd.Pos:
+	default:
+		p.peekError(token.SEMI)
+	}
+}
+
+// expectClosing is expectPeek for the delimiter t closing open, which is
+// pointed at when t is missing.
+func (p *Parser) expectClosing(t token.TokenType, open token.Token) bool {
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	}
+
+	d := p.unexpectedPeek(t)
+	d.Labels = append(d.Labels, diagnostic.Label{Span: diagnostic.TokenSpan(open), Message: "unclosed delimiter"})
+	p.syntaxError(d)
+	return false
 }
 
 func (p *Parser) nextToken() {
 	p.currentToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+	p.currentDoc = p.peekDoc
+
+	// The lexer has reported why the token is ILLEGAL; whatever does not
+	// parse around it until the next statement follows from that.
+	if p.currentTokenIs(token.ILLEGAL) {
+		p.panicking = true
+	}
+
+	switch {
+	case p.currentTokenIs(token.LBRACE):
+		p.braces++
+	case p.currentTokenIs(token.RBRACE) && p.braces > 0:
+		p.braces--
+	}
+
+	p.peekToken = p.scan()
+	p.peekDoc = nil
+	for p.peekTokenIs(token.COMMENT) {
+		p.readCommentGroup()
+	}
+}
+
+func (p *Parser) scan() token.Token {
+	tok := p.l.NextToken()
+
+	// Pick up whatever the lexer reported while scanning the new token.
+	if diags := p.l.Diagnostics(); len(diags) > p.lexerErrors {
+		for _, d := range diags[p.lexerErrors:] {
+			p.report(d)
+		}
+		p.lexerErrors = len(diags)
+	}
+
+	return tok
+}
+
+// readCommentGroup reads the comments starting at the peek token, up to
+// the first blank line or other token. A group that does not trail the
+// current token and ends on the line before the next token documents it.
+func (p *Parser) readCommentGroup() {
+	trailing := p.peekToken.Pos.Line == p.currentToken.End.Line
+	group := &ast.CommentGroup{}
+
+	for p.peekTokenIs(token.COMMENT) && (len(group.List) == 0 || p.peekToken.Pos.Line <= group.End().Line+1) {
+		group.List = append(group.List, &ast.Comment{Token: p.peekToken})
+		p.peekToken = p.scan()
+	}
+	p.comments = append(p.comments, group)
+
+	if !trailing && !p.peekTokenIs(token.COMMENT) && p.peekToken.Pos.Line == group.End().Line+1 {
+		p.peekDoc = group
+	}
 }
 
 // ParseProgram ...
@@ -137,14 +337,17 @@ func (p *Parser) ParseProgram() *ast.Program {
 		}
 		p.nextToken()
 	}
+
+	program.Comments = p.comments
 	return program
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	lparen := p.currentToken
 	p.nextToken()
 	exp := p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectClosing(token.RPAREN, lparen) {
 		return nil
 	}
 
@@ -154,70 +357,547 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.currentToken, Function: fn}
 	exp.Arguments = p.parseCallArguments()
+	if exp.Arguments == nil {
+		return nil
+	}
+	exp.Rparen = p.currentToken
 	return exp
 }
 
 func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
+	return p.parseExpressionList(token.RPAREN)
+}
 
-	if p.peekTokenIs(token.RPAREN) {
+// parseExpressionList parses comma-separated expressions from the current
+// opening delimiter up to end.
+func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+	open := p.currentToken
+
+	if p.peekTokenIs(end) {
 		p.nextToken()
-		return args
+		return list
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
+	list = append(list, p.parseExpression(LOWEST))
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
 		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectClosing(end, open) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.currentToken}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+	array.Rbracket = p.currentToken
+
+	return array
+}
+
+func (p *Parser) parseSuperExpression() ast.Expression {
+	exp := &ast.SuperExpression{Token: p.currentToken}
+
+	if !p.expectPeek(token.DOT) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Method = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.currentToken, Object: object}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Member = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) parseScopedExpression(left ast.Expression) ast.Expression {
+	exp := &ast.ScopedExpression{Token: p.currentToken, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Member = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	return exp
+}
+
+// parseHashLiteral parses {key: value, ...}. Blocks are only parsed after
+// the keywords and headers that introduce them (if, else, fun), so a '{'
+// in expression position always starts a hash.
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.currentToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectClosing(token.RBRACE, hash.Token) {
 		return nil
 	}
+	hash.Rbrace = p.currentToken
 
-	return args
+	return hash
 }
 
+// parseIndexExpression parses xs[i], or the slice xs[low:high] in which
+// both bounds are optional.
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	lbracket := p.currentToken
+
+	var low ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		low = p.parseExpression(LOWEST)
+	}
+
+	if !p.peekTokenIs(token.COLON) {
+		if !p.expectClosing(token.RBRACKET, lbracket) {
+			return nil
+		}
+		return &ast.IndexExpression{Token: lbracket, Left: left, Index: low, Rbracket: p.currentToken}
+	}
+	p.nextToken()
+
+	slice := &ast.SliceExpression{Token: lbracket, Left: left, Low: low}
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		slice.High = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectClosing(token.RBRACKET, lbracket) {
+		return nil
+	}
+	slice.Rbracket = p.currentToken
+
+	return slice
+}
+
+// parseStatement parses a statement and, if it contained a syntax error,
+// skips to the start of the next one. A statement that could not be
+// parsed at all is returned as an *ast.BadStatement.
 func (p *Parser) parseStatement() ast.Statement {
+	start, braces := p.currentToken, p.braces
+	if p.currentTokenIs(token.LBRACE) {
+		// The statement opens a hash literal.
+		braces--
+	}
+
+	stmt := p.parseSimpleStatement()
+
+	if p.panicking {
+		p.synchronize(braces)
+	}
+
+	if stmt == nil {
+		return &ast.BadStatement{Token: start, To: p.currentToken.End}
+	}
+	return stmt
+}
+
+func (p *Parser) parseSimpleStatement() ast.Statement {
 	switch p.currentToken.Type {
 	case token.VAR:
 		return p.parseVarStatement()
+	case token.VALUE:
+		return p.parseValStatement()
+	case token.LET:
+		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.MODULE:
+		return p.parseModuleStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.CLASS:
+		return p.parseClassStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
 }
 
-func (p *Parser) parseVarStatement() *ast.VarStatement {
-	stmt := &ast.VarStatement{Token: p.currentToken}
+// statementKeywords start statements the parser can resume at after a
+// syntax error.
+var statementKeywords = map[token.TokenType]bool{
+	token.VAR:    true,
+	token.VALUE:  true,
+	token.LET:    true,
+	token.RETURN: true,
+	token.FUN:    true,
+	token.CLASS:  true,
+	token.MODULE: true,
+	token.IMPORT: true,
+}
+
+// synchronize skips tokens up to the end of the statement in error: a
+// ';', or the token before a statement keyword, the '}' closing the
+// enclosing block or the end of input. Braces opened since the statement
+// started, at the given brace depth, are skipped whole.
+func (p *Parser) synchronize(braces int) {
+	for !p.currentTokenIs(token.EOF) && !p.peekTokenIs(token.EOF) {
+		if p.braces <= braces && (p.currentTokenIs(token.SEMI) || p.peekTokenIs(token.RBRACE) || statementKeywords[p.peekToken.Type]) {
+			break
+		}
+		p.nextToken()
+	}
+
+	p.panicking = false
+}
+
+func (p *Parser) parseVarStatement() ast.Statement {
+	stmt := &ast.VarStatement{Doc: p.currentDoc, Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	p.declare(stmt.Token, stmt.Name, false)
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	p.expectSemicolon()
+
+	return stmt
+}
+
+func (p *Parser) parseValStatement() ast.Statement {
+	stmt := &ast.ValStatement{Doc: p.currentDoc, Token: p.currentToken}
 	if !p.expectPeek(token.IDENT) {
 		return nil
 	}
 
 	stmt.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	p.declare(stmt.Token, stmt.Name, true)
+
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 
-	for !p.currentTokenIs(token.SEMI) {
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	p.expectSemicolon()
+
+	return stmt
+}
+
+func (p *Parser) parseLetStatement() ast.Statement {
+	stmt := &ast.LetStatement{Doc: p.currentDoc, Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	p.declare(stmt.Token, stmt.Name, true)
+
+	if p.peekTokenIs(token.ASSIGN) {
+		d := p.unexpectedPeek(token.BE)
+		d.Suggestion = &diagnostic.Suggestion{
+			Span:        diagnostic.TokenSpan(p.peekToken),
+			Replacement: "be",
+			Message:     "use `be` instead of `=`",
+		}
+		p.syntaxError(d)
+		return nil
+	}
+
+	if !p.expectPeek(token.BE) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.BEING) {
 		p.nextToken()
+
+		stmt.Being = p.parseBeingBindings()
+		if stmt.Being == nil {
+			return nil
+		}
 	}
 
+	p.expectSemicolon()
+
 	return stmt
 }
 
-func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
-	stmt := &ast.ReturnStatement{Token: p.currentToken}
+func (p *Parser) parseBeingBindings() []*ast.Binding {
+	bindings := []*ast.Binding{}
+
+	for {
+		binding := p.parseBinding()
+		if binding == nil {
+			return nil
+		}
+		bindings = append(bindings, binding)
+
+		if !p.peekTokenIs(token.COMMA) {
+			return bindings
+		}
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseBinding() *ast.Binding {
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	binding := &ast.Binding{
+		Name: &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal},
+	}
+
+	if p.peekTokenIs(token.LPAREN) {
+		p.nextToken()
+
+		binding.Parameters = p.parseFunctionParameters()
+		if binding.Parameters == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.pushScope()
+	for _, param := range binding.Parameters {
+		p.declare(param.Token, param, false)
+	}
+
 	p.nextToken()
+	binding.Value = p.parseExpression(LOWEST)
+	p.popScope()
+
+	return binding
+}
+
+func (p *Parser) parseModuleStatement() ast.Statement {
+	stmt := &ast.ModuleStatement{Doc: p.currentDoc, Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+	p.declare(stmt.Token, stmt.Name, true)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.pushScope()
+	stmt.Body = p.parseBlockStatement()
+	p.popScope()
+
+	p.expectSemicolon()
+
+	return stmt
+}
+
+func (p *Parser) parseClassStatement() ast.Statement {
+	stmt := &ast.ClassStatement{Doc: p.currentDoc, Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	if p.peekTokenIs(token.LTHEN) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Superclass = p.parseExpression(LESSGREATER)
+
+		// Inheriting from an outer class of the same name is fine; with
+		// none in sight the class would inherit from itself.
+		if ident, ok := stmt.Superclass.(*ast.Identifier); ok && ident.Value == stmt.Name.Value {
+			if _, ok := p.lookup(ident.Value); !ok {
+				p.errorf(diagnostic.CyclicInheritance, diagnostic.TokenSpan(ident.Token),
+					"class %s cannot inherit from itself", stmt.Name.Value)
+			}
+		}
+	}
+	p.declare(stmt.Token, stmt.Name, true)
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	lbrace := p.currentToken
+
+	outerFields := p.fields
+	p.fields = nil
+	defer func() { p.fields = outerFields }()
+
+	methods := map[string]*ast.Identifier{}
+	for !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.EOF) {
+		if !p.expectPeek(token.FUN) {
+			return nil
+		}
+
+		method := p.parseMethod()
+		if method == nil {
+			return nil
+		}
+
+		if first, ok := methods[method.Name.Value]; ok {
+			p.report(duplicateError(method.Name, first,
+				"method %s is already defined in class %s", method.Name.Value, stmt.Name.Value))
+		} else {
+			methods[method.Name.Value] = method.Name
+		}
+		stmt.Methods = append(stmt.Methods, method)
+	}
+
+	if !p.expectClosing(token.RBRACE, lbrace) {
+		return nil
+	}
+	stmt.Rbrace = p.currentToken
+	p.blockEnd = p.currentToken
+
+	// Fields are looked up before methods, so a field would hide the
+	// method of the same name.
+	for _, field := range p.fields {
+		if method, ok := methods[field.Value]; ok {
+			p.report(duplicateError(field, method,
+				"field %s would hide method %s of class %s", field.Value, field.Value, stmt.Name.Value))
+		}
+	}
+
+	p.expectSemicolon()
+
+	return stmt
+}
+
+// parseMethod parses a method declaration in a class body, starting at
+// its fun keyword.
+func (p *Parser) parseMethod() *ast.Method {
+	funl := &ast.FunctionLiteral{Token: p.currentToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	method := &ast.Method{Name: &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}, Function: funl}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	funl.Parameters = p.parseFunctionParameters()
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.pushScope()
+	for _, param := range funl.Parameters {
+		p.declare(param.Token, param, false)
+	}
 
-	for !p.currentTokenIs(token.SEMI) {
+	funl.Body = p.parseBlockStatement()
+	p.popScope()
+
+	return method
+}
+
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.currentToken}
+
+	if p.peekTokenIs(token.IDENT) {
 		p.nextToken()
+		stmt.Alias = &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
+		if !p.expectPeek(token.FROM) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.STR) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal}
+
+	name := stmt.Alias
+	if name == nil {
+		name = &ast.Identifier{Token: stmt.Path.Token, Value: stmt.Name()}
+		if !isIdentifier(name.Value) {
+			d := diagnostic.Errorf(diagnostic.InvalidImportName, diagnostic.TokenSpan(stmt.Path.Token),
+				"cannot import %s without a name: %q is not an identifier", stmt.Path, name.Value)
+			d.Suggestion = &diagnostic.Suggestion{
+				Span:        diagnostic.Span{Start: stmt.Path.Pos(), End: stmt.Path.Pos()},
+				Replacement: "Name from ",
+				Message:     "give the module a name",
+			}
+			p.report(d)
+		}
+	}
+	p.declare(stmt.Token, name, true)
+
+	p.expectSemicolon()
+
+	return stmt
+}
+
+// isIdentifier reports whether name lexes as a single identifier.
+func isIdentifier(name string) bool {
+	tok := lexer.New(name).NextToken()
+	return tok.Type == token.IDENT && tok.Literal == name
+}
+
+func (p *Parser) parseReturnStatement() ast.Statement {
+	stmt := &ast.ReturnStatement{Token: p.currentToken}
+
+	// A bare return may be closed by ';', the end of the block or the end
+	// of the input.
+	if p.peekTokenIs(token.SEMI) || p.peekTokenIs(token.RBRACE) || p.peekTokenIs(token.EOF) {
+		if p.peekTokenIs(token.SEMI) {
+			p.nextToken()
+		}
+		return stmt
 	}
 
+	p.nextToken()
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	p.expectSemicolon()
+
 	return stmt
 }
 
@@ -225,29 +905,35 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.currentToken}
 	stmt.Expression = p.parseExpression(LOWEST)
 
-	if p.peekTokenIs(token.SEMI) {
-		p.nextToken()
-	}
+	p.expectSemicolon()
 
 	return stmt
 }
 
 func (p *Parser) suppressPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.syntaxError(diagnostic.Errorf(diagnostic.ExpectedExpression, diagnostic.TokenSpan(p.currentToken),
+		"no prefix parse function for %s found", t))
 }
 
+// parseExpression never returns nil: an expression that cannot be parsed
+// is replaced by an *ast.BadExpression.
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	start := p.currentToken
 	prefix := p.prefixParseFns[p.currentToken.Type]
 
 	if prefix == nil {
 		p.suppressPrefixParseFnError(p.currentToken.Type)
-		return nil
+		return p.badExpression(start)
 	}
 
+	// A bad operand, such as a malformed literal, does not stop the
+	// parser from reading the operators around it.
 	leftExp := prefix()
+	if leftExp == nil {
+		leftExp = p.badExpression(start)
+	}
 
-	for !p.peekTokenIs(token.SEMI) && precedence < p.peekPrecedence() {
+	for !p.panicking && !p.peekTokenIs(token.SEMI) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
 			return leftExp
@@ -255,11 +941,18 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 		p.nextToken()
 		leftExp = infix(leftExp)
+		if leftExp == nil {
+			leftExp = p.badExpression(start)
+		}
 	}
 
 	return leftExp
 }
 
+func (p *Parser) badExpression(start token.Token) ast.Expression {
+	return &ast.BadExpression{Token: start, To: p.currentToken.End}
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.currentToken, Value: p.currentTokenIs(token.TRUE)}
 }
@@ -270,11 +963,12 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
+	lparen := p.currentToken
 
 	p.nextToken()
 	exp.Condition = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectClosing(token.RPAREN, lparen) {
 		return nil
 	}
 
@@ -311,6 +1005,20 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		p.nextToken()
 	}
 
+	if p.currentTokenIs(token.EOF) {
+		d := diagnostic.Errorf(diagnostic.UnexpectedToken, diagnostic.TokenSpan(p.currentToken),
+			"expected next token to be }, got EOF instead")
+		d.Labels = []diagnostic.Label{{Span: diagnostic.TokenSpan(block.Token), Message: "unclosed delimiter"}}
+		d.Suggestion = &diagnostic.Suggestion{
+			Span:        diagnostic.Span{Start: p.currentToken.Pos, End: p.currentToken.Pos},
+			Replacement: "}",
+			Message:     "insert `}`",
+		}
+		p.syntaxError(d)
+	}
+
+	block.Rbrace = p.currentToken
+	p.blockEnd = p.currentToken
 	return block
 }
 
@@ -325,6 +1033,34 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return expression
 }
 
+// parseNotExpression parses the word operator `not`, which binds looser
+// than comparisons: not a == b is not (a == b).
+func (p *Parser) parseNotExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    p.currentToken,
+		Operator: p.currentToken.Literal,
+	}
+
+	p.nextToken()
+	expression.RightHand = p.parseExpression(NOT)
+	return expression
+}
+
+func (p *Parser) parseLogicalExpression(leftHand ast.Expression) ast.Expression {
+	expression := &ast.LogicalExpression{
+		Token:    p.currentToken,
+		Operator: p.currentToken.Literal,
+		LeftHand: leftHand,
+	}
+
+	precedence := p.currentPrecedence()
+	p.nextToken()
+
+	expression.RightHand = p.parseExpression(precedence)
+
+	return expression
+}
+
 func (p *Parser) parseInfixExpression(leftHand ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
 		Token:    p.currentToken,
@@ -333,6 +1069,10 @@ func (p *Parser) parseInfixExpression(leftHand ast.Expression) ast.Expression {
 	}
 
 	precedence := p.currentPrecedence()
+	if p.currentTokenIs(token.POW) {
+		// ^ is right-associative: 2 ^ 3 ^ 2 is 2 ^ (3 ^ 2).
+		precedence--
+	}
 	p.nextToken()
 
 	expression.RightHand = p.parseExpression(precedence)
@@ -340,6 +1080,35 @@ func (p *Parser) parseInfixExpression(leftHand ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{Token: p.currentToken, Target: target}
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+		if decl, ok := p.lookup(target.Value); ok && decl.immutable {
+			p.report(assignToValError(target, decl))
+		}
+	case *ast.MemberExpression:
+		if self, ok := target.Object.(*ast.Identifier); ok && self.Value == "self" {
+			p.fields = append(p.fields, target.Member)
+		}
+		// Storing into a field does not rebind the object, so it is
+		// allowed even when that is a val binding.
+	case *ast.IndexExpression:
+		// Storing into an element does not rebind the collection, so it
+		// is allowed even when that is a val binding.
+	default:
+		p.errorf(diagnostic.InvalidAssignTarget, diagnostic.Span{Start: target.Pos(), End: target.End()},
+			"invalid assignment target %s", target.String())
+	}
+
+	// Assignment is right-associative: a = b = c is a = (b = c).
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.currentToken, Value: p.currentToken.Literal}
 }
@@ -347,11 +1116,17 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	intLiteral := &ast.IntegerLiteral{Token: p.currentToken}
 
-	value, err := strconv.ParseInt(p.currentToken.Literal, 0, 64)
+	digits, base := integerDigits(p.currentToken.Literal)
+	value, err := strconv.ParseInt(digits, base, 64)
 
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.currentToken.Literal)
-		p.errors = append(p.errors, msg)
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			// Literals too large for an int64 are arbitrary-precision.
+			if intLiteral.Big, ok = new(big.Int).SetString(digits, base); ok {
+				return intLiteral
+			}
+		}
+		p.errorf(diagnostic.InvalidInteger, diagnostic.TokenSpan(p.currentToken), "could not parse %q as integer", p.currentToken.Literal)
 		return nil
 	}
 
@@ -359,6 +1134,78 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return intLiteral
 }
 
+// integerDigits returns the digits of an integer literal without its base
+// prefix and separators, and the base they are written in. Unlike Go, a
+// leading zero does not make a literal octal: 010 is ten.
+func integerDigits(literal string) (string, int) {
+	digits := strings.Replace(literal, "_", "", -1)
+
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X':
+			return digits[2:], 16
+		case 'o', 'O':
+			return digits[2:], 8
+		case 'b', 'B':
+			return digits[2:], 2
+		}
+	}
+
+	return digits, 10
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	floatLiteral := &ast.FloatLiteral{Token: p.currentToken}
+
+	value, err := strconv.ParseFloat(strings.Replace(p.currentToken.Literal, "_", "", -1), 64)
+
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			p.errorf(diagnostic.InvalidFloat, diagnostic.TokenSpan(p.currentToken), "float literal %s is out of range", p.currentToken.Literal)
+			return nil
+		}
+		p.errorf(diagnostic.InvalidFloat, diagnostic.TokenSpan(p.currentToken), "could not parse %q as float", p.currentToken.Literal)
+		return nil
+	}
+
+	floatLiteral.Value = value
+	return floatLiteral
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal}
+}
+
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	exp := &ast.InterpolatedString{Token: p.currentToken}
+	exp.Parts = append(exp.Parts, &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal})
+
+	for {
+		open := p.currentToken
+		p.nextToken()
+		exp.Parts = append(exp.Parts, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.TMPLMIDDLE) && !p.peekTokenIs(token.TMPLTAIL) {
+			d := p.unexpectedPeek(token.RBRACE)
+			d.Labels = []diagnostic.Label{{Span: diagnostic.TokenSpan(open), Message: "unclosed interpolation"}}
+			p.syntaxError(d)
+			return nil
+		}
+		p.nextToken()
+
+		exp.Parts = append(exp.Parts, &ast.StringLiteral{Token: p.currentToken, Value: p.currentToken.Literal})
+		if p.currentTokenIs(token.TMPLTAIL) {
+			return exp
+		}
+	}
+}
+
+// parseIllegal skips an ILLEGAL token; the lexer has already reported why
+// it is illegal.
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	funl := &ast.FunctionLiteral{Token: p.currentToken}
 
@@ -372,12 +1219,20 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	p.pushScope()
+	for _, param := range funl.Parameters {
+		p.declare(param.Token, param, false)
+	}
+
 	funl.Body = p.parseBlockStatement()
+	p.popScope()
+
 	return funl
 }
 
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	identifiers := []*ast.Identifier{}
+	lparen := p.currentToken
 
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
@@ -396,13 +1251,72 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 		identifiers = append(identifiers, ident)
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectClosing(token.RPAREN, lparen) {
 		return nil
 	}
 
 	return identifiers
 }
 
+// declaration is what the parser remembers about a declared name: the
+// keyword that introduced it (var, val, let, or the name itself for
+// parameters) and whether it can be reassigned.
+type declaration struct {
+	keyword   token.Token
+	name      *ast.Identifier
+	immutable bool
+}
+
+func (p *Parser) pushScope() { p.scopes = append(p.scopes, map[string]declaration{}) }
+func (p *Parser) popScope()  { p.scopes = p.scopes[:len(p.scopes)-1] }
+
+func (p *Parser) declare(keyword token.Token, name *ast.Identifier, immutable bool) {
+	p.scopes[len(p.scopes)-1][name.Value] = declaration{keyword: keyword, name: name, immutable: immutable}
+}
+
+// lookup returns the innermost declaration of name visible to the parser.
+func (p *Parser) lookup(name string) (declaration, bool) {
+	for i := len(p.scopes) - 1; i >= 0; i-- {
+		if decl, ok := p.scopes[i][name]; ok {
+			return decl, true
+		}
+	}
+	return declaration{}, false
+}
+
+// assignToValError reports assigning to target, declared immutable by
+// decl. A val binding can be fixed by declaring it with var instead.
+func assignToValError(target *ast.Identifier, decl declaration) diagnostic.Diagnostic {
+	d := diagnostic.Errorf(diagnostic.AssignToVal, diagnostic.TokenSpan(target.Token),
+		"cannot assign to val binding %s", target.Value)
+	d.Labels = []diagnostic.Label{{
+		Span:    diagnostic.TokenSpan(decl.name.Token),
+		Message: fmt.Sprintf("%s declared with %s here", decl.name.Value, decl.keyword.Literal),
+	}}
+
+	if decl.keyword.Type == token.VALUE {
+		d.Suggestion = &diagnostic.Suggestion{
+			Span:        diagnostic.TokenSpan(decl.keyword),
+			Replacement: "var",
+			Message:     fmt.Sprintf("make %s mutable: `var %s`", decl.name.Value, decl.name.Value),
+		}
+	}
+
+	return d
+}
+
+// duplicateError reports that name defines a class member again, with
+// a label on first, the definition it clashes with.
+func duplicateError(name, first *ast.Identifier, format string, a ...interface{}) diagnostic.Diagnostic {
+	d := diagnostic.Errorf(diagnostic.DuplicateDefinition, diagnostic.TokenSpan(name.Token), format, a...)
+	d.Labels = []diagnostic.Label{{
+		Span:    diagnostic.TokenSpan(first.Token),
+		Message: fmt.Sprintf("method %s defined here", first.Value),
+	}}
+
+	return d
+}
+
 func (p *Parser) currentTokenIs(t token.TokenType) bool { return p.currentToken.Type == t }
 func (p *Parser) peekTokenIs(t token.TokenType) bool    { return p.peekToken.Type == t }
 
@@ -2,8 +2,10 @@ package parser
 
 import (
 	"clint/ast"
+	"clint/diagnostic"
 	"clint/lexer"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -22,42 +24,99 @@ func testIdentifier(test *testing.T, exp ast.Expression, value string) bool {
 }
 
 func TestVarStatement(test *testing.T) {
-	input := `
-	var x = 5;
-	var y = 10;
-	var foobar = 123456;
-	`
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"var x = 5;", "x", 5},
+		{"var y = true;", "y", true},
+		{"var foobar = y;", "foobar", "y"},
+		{"var z = 123456", "z", 123456},
+	}
 
-	lex := lexer.New(input)
-	parser := New(lex)
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		parser := New(lex)
 
-	program := parser.ParseProgram()
-	checkParserErrors(test, parser)
+		program := parser.ParseProgram()
+		checkParserErrors(test, parser)
 
-	if program == nil {
-		test.Fatalf("ParseProgram() returned nil")
-	}
+		if program == nil {
+			test.Fatalf("ParseProgram() returned nil")
+		}
+
+		if len(program.Statements) != 1 {
+			test.Fatalf("program.Statements does not contain 1 statement, got=%d", len(program.Statements))
+		}
 
-	if len(program.Statements) != 3 {
-		test.Fatalf("program.Statements does not contain 3 statements, got=%d", len(program.Statements))
+		stmt := program.Statements[0]
+		if !testVarStatement(test, stmt, tt.expectedIdentifier) {
+			return
+		}
+
+		val := stmt.(*ast.VarStatement).Value
+		if !testLiteralExpression(test, val, tt.expectedValue) {
+			return
+		}
 	}
+}
 
+func TestVarStatementValues(test *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input    string
+		expected string
 	}{
-		{"x"},
-		{"y"},
-		{"foobar"},
+		{"var x = 1 + 2 * 3;", "var x = (1 + (2 * 3));"},
+		{"var x = -(a + b) * c", "var x = ((-(a + b)) * c);"},
+		{"var add = fun(x, y) { x + y; };", "var add = fun(x, y) (x + y);"},
+		{"var r = add(1, mul(2, 3));", "var r = add(1, mul(2, 3));"},
+		{"var a = 1; var b = a", "var a = 1;var b = a;"},
 	}
 
-	for i, tt := range tests {
-		stmt := program.Statements[i]
-		if !testVarStatement(test, stmt, tt.expectedIdentifier) {
-			return
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if actual := program.String(); actual != tt.expected {
+			test.Errorf("expected=%q, got=%q", tt.expected, actual)
 		}
 	}
 }
 
+func TestVarStatementFunctionValue(test *testing.T) {
+	input := "var add = fun(x, y) { x + y }; add(1, 2)"
+
+	lex := lexer.New(input)
+	p := New(lex)
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if len(program.Statements) != 2 {
+		test.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.VarStatement)
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		test.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+
+	if len(function.Parameters) != 2 {
+		test.Fatalf("wrong number of parameters. got=%d", len(function.Parameters))
+	}
+
+	body := function.Body.Statements[0].(*ast.ExpressionStatement)
+	testInfixExpression(test, body.Expression, "x", "+", "y")
+
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	testIdentifier(test, call.Function, "add")
+	testLiteralExpression(test, call.Arguments[0], 1)
+	testLiteralExpression(test, call.Arguments[1], 2)
+}
+
 func checkParserErrors(test *testing.T, parser *Parser) {
 	errors := parser.Errors()
 	if len(errors) == 0 {
@@ -100,33 +159,63 @@ func testVarStatement(test *testing.T, stmt ast.Statement, name string) bool {
 
 // TestReturnStatement ...
 func TestReturnStatement(test *testing.T) {
-	input := `
-	return 5;
-	return 10;
-	return 42;
-	`
-
-	lex := lexer.New(input)
-	parser := New(lex)
+	tests := []struct {
+		input         string
+		expectedValue interface{}
+	}{
+		{"return 5;", 5},
+		{"return true;", true},
+		{"return foobar", "foobar"},
+	}
 
-	program := parser.ParseProgram()
-	checkParserErrors(test, parser)
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		parser := New(lex)
 
-	if len(program.Statements) != 3 {
-		test.Fatalf("program.Statements does not contain 3 statements.got=%d", len(program.Statements))
-	}
+		program := parser.ParseProgram()
+		checkParserErrors(test, parser)
 
-	for _, stmt := range program.Statements {
-		returnStmt, ok := stmt.(*ast.ReturnStatement)
+		if len(program.Statements) != 1 {
+			test.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
 
+		returnStmt, ok := program.Statements[0].(*ast.ReturnStatement)
 		if !ok {
-			test.Errorf("Statement not *ast.returnStatement. got=%T", stmt)
-			continue
+			test.Fatalf("Statement not *ast.returnStatement. got=%T", program.Statements[0])
 		}
 
 		if returnStmt.TokenLiteral() != "return" {
 			test.Errorf("returnStmt.TokenLiteral not 'return', got %q", returnStmt.TokenLiteral())
 		}
+
+		if !testLiteralExpression(test, returnStmt.ReturnValue, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestReturnStatementValues(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"return a * (b + c);", "return (a * (b + c));"},
+		{"return fun(x) { x };", "return fun(x) x;"},
+		{"return f(g(1), 2)", "return f(g(1), 2);"},
+		{"return;", "return ;"},
+		{"fun() { return }", "fun() return ;"},
+		{"fun() { return x }", "fun() return x;"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if actual := program.String(); actual != tt.expected {
+			test.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
 	}
 }
 
@@ -140,6 +229,8 @@ func testLiteralExpression(
 		return testIntegerLiteral(t, exp, int64(v))
 	case int64:
 		return testIntegerLiteral(t, exp, v)
+	case float64:
+		return testFloatLiteral(t, exp, v)
 	case string:
 		return testIdentifier(t, exp, v)
 	case bool:
@@ -401,6 +492,8 @@ func TestParsingInfixExpressions(test *testing.T) {
 		{"5 - 5;", 5, "-", 5},
 		{"5 * 5;", 5, "*", 5},
 		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 ^ 5;", 5, "^", 5},
 		{"5 > 5;", 5, ">", 5},
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
@@ -634,7 +727,7 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	return true
 }
 
-func testOperatorPrecedenceParsing(test *testing.T) {
+func TestOperatorPrecedenceParsing(test *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
@@ -647,6 +740,14 @@ func testOperatorPrecedenceParsing(test *testing.T) {
 			"!-a",
 			"(!(-a))",
 		},
+		{
+			"a!=b",
+			"(a != b)",
+		},
+		{
+			"!empty?(xs) == done!",
+			"((!empty?(xs)) == done!)",
+		},
 		{
 			"a + b + c",
 			"((a + b) + c)",
@@ -739,6 +840,78 @@ func testOperatorPrecedenceParsing(test *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"a and b or c",
+			"((a and b) or c)",
+		},
+		{
+			"a or b and c",
+			"(a or (b and c))",
+		},
+		{
+			"a < b and b < c",
+			"((a < b) and (b < c))",
+		},
+		{
+			"a == b or c != d",
+			"((a == b) or (c != d))",
+		},
+		{
+			"not a == b",
+			"(not (a == b))",
+		},
+		{
+			"not a and b",
+			"((not a) and b)",
+		},
+		{
+			"not not a",
+			"(not (not a))",
+		},
+		{
+			"a equal b + 1",
+			"(a equal (b + 1))",
+		},
+		{
+			"a equal b and not c",
+			"((a equal b) and (not c))",
+		},
+		{
+			"x = a or b",
+			"x = (a or b)",
+		},
+		{
+			"a + b % c",
+			"(a + (b % c))",
+		},
+		{
+			"a * b % c",
+			"((a * b) % c)",
+		},
+		{
+			"2 ^ 3 ^ 2",
+			"(2 ^ (3 ^ 2))",
+		},
+		{
+			"-2 ^ 2",
+			"(-(2 ^ 2))",
+		},
+		{
+			"2 ^ -1",
+			"(2 ^ (-1))",
+		},
+		{
+			"a * b ^ c",
+			"(a * (b ^ c))",
+		},
+		{
+			"a ^ b * c",
+			"((a ^ b) * c)",
+		},
+		{
+			"f(x) ^ 2",
+			"(f(x) ^ 2)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -754,3 +927,1291 @@ func testOperatorPrecedenceParsing(test *testing.T) {
 		}
 	}
 }
+
+func TestValStatement(test *testing.T) {
+	input := "val answer = 6 * 7;"
+
+	lex := lexer.New(input)
+	p := New(lex)
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if len(program.Statements) != 1 {
+		test.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ValStatement)
+	if !ok {
+		test.Fatalf("stmt not *ast.ValStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Name.Value != "answer" {
+		test.Errorf("stmt.Name.Value not 'answer'. got=%s", stmt.Name.Value)
+	}
+
+	testInfixExpression(test, stmt.Value, 6, "*", 7)
+
+	if program.String() != "val answer = (6 * 7);" {
+		test.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestAssignExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"x = y = 1 + 2", "x = y = (1 + 2)"},
+		{"x = x + 1 == 2", "x = ((x + 1) == 2)"},
+		{"var x = 1; x = 2", "var x = 1;x = 2"},
+		{"val x = 1; fun(x) { x = 2 }", "val x = 1;fun(x) x = 2"},
+		{"val x = 1; fun() { var x = 0; x = 2 }", "val x = 1;fun() var x = 0;x = 2"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if actual := program.String(); actual != tt.expected {
+			test.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestAssignExpressionErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"val x = 1; x = 2", "1:12: cannot assign to val binding x"},
+		{"val x = 1; fun() { x = 2 }", "1:20: cannot assign to val binding x"},
+		{"fun() { val y = 1; fun() { y = 2 } }", "1:28: cannot assign to val binding y"},
+		{"1 = 2", "1:1: invalid assignment target 1"},
+		{"a + b = 2", "1:1: invalid assignment target (a + b)"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			test.Errorf("expected error for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			test.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLetStatement(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let total be a + b;", "let total be (a + b);"},
+		{"let x be 5", "let x be 5;"},
+		{"let area be w * h being w = 3, h = 4;", "let area be (w * h) being w = 3, h = 4;"},
+		{"let r be area(2, 5) being area(w, h) = w * h", "let r be area(2, 5) being area(w, h) = (w * h);"},
+		{"let n be f() being f() = 1;", "let n be f() being f() = 1;"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if len(program.Statements) != 1 {
+			test.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		if _, ok := program.Statements[0].(*ast.LetStatement); !ok {
+			test.Fatalf("stmt not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+
+		actual := program.String()
+		if actual != tt.expected {
+			test.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+
+		// The printed form must parse back to itself.
+		reparsed := New(lexer.New(actual))
+		again := reparsed.ParseProgram()
+		checkParserErrors(test, reparsed)
+
+		if again.String() != actual {
+			test.Errorf("String() does not round-trip. first=%q, second=%q", actual, again.String())
+		}
+	}
+}
+
+func TestLetStatementBeingBindings(test *testing.T) {
+	input := "let r be area(2, 5) being area(w, h) = w * h, k = 2"
+
+	lex := lexer.New(input)
+	p := New(lex)
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	stmt := program.Statements[0].(*ast.LetStatement)
+
+	if stmt.Name.Value != "r" {
+		test.Errorf("stmt.Name.Value not 'r'. got=%s", stmt.Name.Value)
+	}
+
+	if len(stmt.Being) != 2 {
+		test.Fatalf("stmt.Being does not contain 2 bindings. got=%d", len(stmt.Being))
+	}
+
+	area := stmt.Being[0]
+	if area.Name.Value != "area" || len(area.Parameters) != 2 {
+		test.Errorf("wrong function binding. got=%s", area.String())
+	}
+	testInfixExpression(test, area.Value, "w", "*", "h")
+
+	k := stmt.Being[1]
+	if k.Name.Value != "k" || k.Parameters != nil {
+		test.Errorf("wrong value binding. got=%s", k.String())
+	}
+	testLiteralExpression(test, k.Value, 2)
+}
+
+func TestLetStatementErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"let x = 5", "1:7: expected next token to be BE, got = instead"},
+		{"let x be 1; x = 2", "1:13: cannot assign to val binding x"},
+		{"let x be 1 being = 2", "1:18: expected next token to be IDENT, got = instead"},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			test.Errorf("expected error for %q, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			test.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLogicalExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		left     interface{}
+		operator string
+		right    interface{}
+	}{
+		{"a and b", "a", "and", "b"},
+		{"true or false", true, "or", false},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.LogicalExpression)
+		if !ok {
+			test.Fatalf("exp is not ast.LogicalExpression. got=%T", stmt.Expression)
+		}
+
+		if exp.Operator != tt.operator {
+			test.Errorf("exp.Operator is not '%s'. got=%s", tt.operator, exp.Operator)
+		}
+
+		testLiteralExpression(test, exp.LeftHand, tt.left)
+		testLiteralExpression(test, exp.RightHand, tt.right)
+	}
+}
+
+func testFloatLiteral(t *testing.T, fl ast.Expression, value float64) bool {
+	float, ok := fl.(*ast.FloatLiteral)
+	if !ok {
+		t.Errorf("fl not *ast.FloatLiteral. got=%T", fl)
+		return false
+	}
+
+	if float.Value != value {
+		t.Errorf("float.Value not %g. got=%g", value, float.Value)
+		return false
+	}
+
+	return true
+}
+
+func TestIntegerLiteralBases(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0x1F", 31},
+		{"0Xff", 255},
+		{"0o17", 15},
+		{"0b101", 5},
+		{"1_000_000", 1000000},
+		{"0b1111_0000", 240},
+		{"010", 10},
+		{"9223372036854775807", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			test.Fatalf("%q: exp not *ast.IntegerLiteral. got=%T", tt.input, stmt.Expression)
+		}
+
+		if literal.Value != tt.expected {
+			test.Errorf("%q: wrong value. expected=%d, got=%d", tt.input, tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestBigIntegerLiteral(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808", "9223372036854775808"},
+		{"0x1_0000_0000_0000_0000", "18446744073709551616"},
+		{"1_000_000_000_000_000_000_000", "1000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			test.Fatalf("%q: exp not *ast.IntegerLiteral. got=%T", tt.input, stmt.Expression)
+		}
+
+		if literal.Big == nil || literal.Big.String() != tt.expected {
+			test.Errorf("%q: wrong Big. expected=%s, got=%v", tt.input, tt.expected, literal.Big)
+		}
+
+		if literal.String() != tt.input {
+			test.Errorf("%q: String() does not round-trip. got=%q", tt.input, literal.String())
+		}
+	}
+
+	p := New(lexer.New("9223372036854775807"))
+	program := p.ParseProgram()
+	if literal := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral); literal.Big != nil {
+		test.Errorf("int64 literal should not be big. got=%s", literal.Big)
+	}
+}
+
+func TestNumberLiteralErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"1e400", "1:1: float literal 1e400 is out of range"},
+		{"var n = 1__0 + 2", "1:10: '_' must separate successive digits"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			test.Errorf("%q: expected 1 error, got=%q", tt.input, errors)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestFloatLiteralExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{".5", 0.5},
+		{"6.02e23", 6.02e23},
+		{"1e-3", 0.001},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, tt := range tests {
+		lex := lexer.New(tt.input)
+		p := New(lex)
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		testLiteralExpression(test, stmt.Expression, tt.expected)
+
+		if stmt.Expression.TokenLiteral() != strings.TrimSuffix(tt.input, ";") {
+			test.Errorf("TokenLiteral not %q. got=%q", tt.input, stmt.Expression.TokenLiteral())
+		}
+	}
+
+	p := New(lexer.New("1.5 * -2.0 + 3"))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if program.String() != "((1.5 * (-2.0)) + 3)" {
+		test.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestStringLiteralExpression(test *testing.T) {
+	input := `"hello \"world\"\n";`
+
+	lex := lexer.New(input)
+	p := New(lex)
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	if !ok {
+		test.Fatalf("exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	}
+
+	if literal.Value != "hello \"world\"\n" {
+		test.Errorf("literal.Value not %q. got=%q", "hello \"world\"\n", literal.Value)
+	}
+
+	if literal.String() != `"hello \"world\"\n"` {
+		test.Errorf("literal.String() does not round-trip. got=%s", literal.String())
+	}
+}
+
+func TestUnterminatedStringError(test *testing.T) {
+	lex := lexer.New(`var s = "abc`)
+	p := New(lex)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		test.Fatalf("expected 1 error, got=%d (%q)", len(errors), errors)
+	}
+
+	if errors[0] != "1:9: unterminated string literal" {
+		test.Errorf("wrong error. got=%q", errors[0])
+	}
+}
+
+func TestInterpolatedString(test *testing.T) {
+	input := `"Hello, ${name}! ${a + b * 2} left"`
+
+	lex := lexer.New(input)
+	p := New(lex)
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		test.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	if len(str.Parts) != 5 {
+		test.Fatalf("wrong number of parts. want=5, got=%d", len(str.Parts))
+	}
+
+	texts := []string{"Hello, ", "! ", " left"}
+	for i, text := range texts {
+		literal, ok := str.Parts[i*2].(*ast.StringLiteral)
+		if !ok || literal.Value != text {
+			test.Errorf("Parts[%d] is not text %q. got=%s", i*2, text, str.Parts[i*2])
+		}
+	}
+
+	testIdentifier(test, str.Parts[1], "name")
+
+	if str.Parts[3].String() != "(a + (b * 2))" {
+		test.Errorf("Parts[3] wrong. got=%s", str.Parts[3])
+	}
+}
+
+func TestInterpolatedStringRoundTrip(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello, ${name}!"`, `"Hello, ${name}!"`},
+		{`"${a}${b}"`, `"${a}${b}"`},
+		{`"sum: ${1 + 2}\n"`, `"sum: ${(1 + 2)}\n"`},
+		{`"outer ${"inner ${x}"} done"`, `"outer ${"inner ${x}"} done"`},
+		{`"price: $5 \${literal}"`, `"price: $5 \${literal}"`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			test.Errorf("expected=%s, got=%s", tt.expected, actual)
+		}
+
+		reparsed := New(lexer.New(actual))
+		again := reparsed.ParseProgram()
+		checkParserErrors(test, reparsed)
+
+		if again.String() != actual {
+			test.Errorf("String() does not round-trip. first=%s, second=%s", actual, again.String())
+		}
+	}
+}
+
+func TestInterpolatedStringErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`"a ${x y} b"`, "1:8: expected next token to be }, got IDENT instead"},
+		{`"a ${x`, "1:7: expected next token to be }, got EOF instead"},
+		{`"a ${}"`, "1:6: no prefix parse function for TMPLTAIL found"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			test.Errorf("%s: expected error, got none", tt.input)
+			continue
+		}
+
+		if errors[0] != tt.expectedError {
+			test.Errorf("%s: wrong error. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestNodePositions(test *testing.T) {
+	input := "var add = fun(a, b) {\n  return a + b;\n};\nadd(1, 2 * 3);\nlet s be \"x${n}y\""
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	varStmt := program.Statements[0].(*ast.VarStatement)
+	function := varStmt.Value.(*ast.FunctionLiteral)
+	returnStmt := function.Body.Statements[0].(*ast.ReturnStatement)
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	letStmt := program.Statements[2].(*ast.LetStatement)
+
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{varStmt, "var add = fun(a, b) {\n  return a + b;\n}"},
+		{function.Body, "{\n  return a + b;\n}"},
+		{returnStmt, "return a + b"},
+		{returnStmt.ReturnValue, "a + b"},
+		{call, "add(1, 2 * 3)"},
+		{call.Arguments[1], "2 * 3"},
+		{letStmt, "let s be \"x${n}y\""},
+		{letStmt.Value, "\"x${n}y\""},
+		{program, input},
+	}
+
+	for _, tt := range tests {
+		source := input[tt.node.Pos().Offset:tt.node.End().Offset]
+		if source != tt.expected {
+			test.Errorf("wrong span for %T. expected=%q, got=%q", tt.node, tt.expected, source)
+		}
+	}
+
+	if pos := returnStmt.Pos(); pos.Line != 2 || pos.Column != 3 {
+		test.Errorf("wrong position for return. got=%s", pos)
+	}
+}
+
+func TestDiagnostics(test *testing.T) {
+	tests := []struct {
+		input       string
+		code        string
+		span        string
+		labels      []string
+		replacement string
+	}{
+		{"add(1, 2", diagnostic.UnexpectedToken, "1:9-1:9", []string{"1:4 unclosed delimiter"}, ")"},
+		{"(1 + 2", diagnostic.UnexpectedToken, "1:7-1:7", []string{"1:1 unclosed delimiter"}, ")"},
+		{"fun(a, b { a }", diagnostic.UnexpectedToken, "1:10-1:11", []string{"1:4 unclosed delimiter"}, ")"},
+		{"if (x { 1 }", diagnostic.UnexpectedToken, "1:7-1:8", []string{"1:4 unclosed delimiter"}, ")"},
+		{"fun() { 1", diagnostic.UnexpectedToken, "1:10-1:10", []string{"1:7 unclosed delimiter"}, "}"},
+		{"let x = 5", diagnostic.UnexpectedToken, "1:7-1:8", nil, "be"},
+		{"val x = 1; x = 2", diagnostic.AssignToVal, "1:12-1:13", []string{"1:5 x declared with val here"}, "var"},
+		{"let x be 1; x = 2", diagnostic.AssignToVal, "1:13-1:14", []string{"1:5 x declared with let here"}, ""},
+		{"1 = 2", diagnostic.InvalidAssignTarget, "1:1-1:2", nil, ""},
+		{"var x = )", diagnostic.ExpectedExpression, "1:9-1:10", nil, ""},
+		{"1e400", diagnostic.InvalidFloat, "1:1-1:6", nil, ""},
+		{`"${a b}"`, diagnostic.UnexpectedToken, "1:6-1:7", []string{"1:1 unclosed interpolation"}, "}"},
+		{"var s = \"abc", diagnostic.UnterminatedString, "1:9-1:13", nil, ""},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) == 0 {
+			test.Errorf("%q: expected a diagnostic, got none", tt.input)
+			continue
+		}
+		d := diagnostics[0]
+
+		if d.Code != tt.code {
+			test.Errorf("%q: wrong code. expected=%s, got=%s (%s)", tt.input, tt.code, d.Code, d.Error())
+		}
+
+		if span := d.Span.Start.String() + "-" + d.Span.End.String(); span != tt.span {
+			test.Errorf("%q: wrong span. expected=%s, got=%s", tt.input, tt.span, span)
+		}
+
+		labels := []string{}
+		for _, label := range d.Labels {
+			labels = append(labels, label.Span.Start.String()+" "+label.Message)
+		}
+		if len(labels) != len(tt.labels) {
+			test.Errorf("%q: wrong labels. expected=%q, got=%q", tt.input, tt.labels, labels)
+		} else {
+			for i := range labels {
+				if labels[i] != tt.labels[i] {
+					test.Errorf("%q: wrong label. expected=%q, got=%q", tt.input, tt.labels[i], labels[i])
+				}
+			}
+		}
+
+		replacement := ""
+		if d.Suggestion != nil {
+			replacement = d.Suggestion.Replacement
+		}
+		if replacement != tt.replacement {
+			test.Errorf("%q: wrong suggestion. expected=%q, got=%q", tt.input, tt.replacement, replacement)
+		}
+	}
+}
+
+func TestErrorRecovery(test *testing.T) {
+	tests := []struct {
+		input      string
+		errors     []string
+		statements []string
+	}{
+		{
+			"var = 5; var z = 2; z",
+			[]string{"1:5: expected next token to be IDENT, got = instead"},
+			[]string{"<bad statement>", "var z = 2;", "z"},
+		},
+		{
+			"let y = 3; y",
+			[]string{"1:7: expected next token to be BE, got = instead"},
+			[]string{"<bad statement>", "y"},
+		},
+		{
+			"add(1, 2 + * 3; 1",
+			[]string{"1:12: no prefix parse function for * found"},
+			[]string{"<bad expression>", "1"},
+		},
+		{
+			"if (x { 1 } var a = 1",
+			[]string{"1:7: expected next token to be ), got { instead"},
+			[]string{"<bad expression>", "var a = 1;"},
+		},
+		{
+			"var f = fun(x) { var = 1; x }; f",
+			[]string{"1:22: expected next token to be IDENT, got = instead"},
+			[]string{"var f = fun(x) <bad statement>x;", "f"},
+		},
+		{
+			"1 + ; 2 * ; 3",
+			[]string{"1:5: no prefix parse function for ; found", "1:11: no prefix parse function for ; found"},
+			[]string{"(1 + <bad expression>)", "(2 * <bad expression>)", "3"},
+		},
+		{
+			"var x = (1 + 2\nreturn x",
+			[]string{"2:1: expected next token to be ), got RETURN instead"},
+			[]string{"var x = <bad expression>;", "return x;"},
+		},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.errors) {
+			test.Errorf("%q: wrong number of errors. expected=%q, got=%q", tt.input, tt.errors, errors)
+			continue
+		}
+		for i, err := range errors {
+			if err != tt.errors[i] {
+				test.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.errors[i], err)
+			}
+		}
+
+		if len(program.Statements) != len(tt.statements) {
+			test.Errorf("%q: wrong number of statements. expected=%d, got=%d", tt.input, len(tt.statements), len(program.Statements))
+			continue
+		}
+		for i, stmt := range program.Statements {
+			if stmt.String() != tt.statements[i] {
+				test.Errorf("%q: wrong statement %d. expected=%q, got=%q", tt.input, i, tt.statements[i], stmt.String())
+			}
+		}
+	}
+}
+
+func TestBadNodePositions(test *testing.T) {
+	p := New(lexer.New("var = 5; 1"))
+	program := p.ParseProgram()
+
+	bad, ok := program.Statements[0].(*ast.BadStatement)
+	if !ok {
+		test.Fatalf("statement is not *ast.BadStatement. got=%T", program.Statements[0])
+	}
+
+	if bad.Pos().String() != "1:1" || bad.End().String() != "1:9" {
+		test.Errorf("wrong span. got=%s-%s", bad.Pos(), bad.End())
+	}
+}
+
+func TestComments(test *testing.T) {
+	input := `// Package header.
+
+// The answer.
+// Computed slowly.
+var answer = 42; // trailing
+
+/* Unused. */
+
+val pi = 3 /* inline */ + 0.14;
+/*
+ * Greets.
+ */
+let greet be fun(name) { name };
+answer`
+
+	p := New(lexer.NewWithMode("", input, lexer.ScanComments))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if len(program.Statements) != 4 {
+		test.Fatalf("wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	groups := []string{}
+	for _, group := range program.Comments {
+		groups = append(groups, group.Text())
+	}
+	expected := []string{"Package header.", "The answer.\nComputed slowly.", "trailing", "Unused.", "inline", "Greets."}
+	if strings.Join(groups, "|") != strings.Join(expected, "|") {
+		test.Errorf("wrong comment groups. expected=%q, got=%q", expected, groups)
+	}
+
+	docs := []*ast.CommentGroup{
+		program.Statements[0].(*ast.VarStatement).Doc,
+		program.Statements[1].(*ast.ValStatement).Doc,
+		program.Statements[2].(*ast.LetStatement).Doc,
+	}
+	expectedDocs := []string{"The answer.\nComputed slowly.", "", "Greets."}
+
+	for i, doc := range docs {
+		text := ""
+		if doc != nil {
+			text = doc.Text()
+		}
+		if text != expectedDocs[i] {
+			test.Errorf("statement %d: wrong doc. expected=%q, got=%q", i, expectedDocs[i], text)
+		}
+	}
+
+	if program.Statements[1].String() != "val pi = (3 + 0.14);" {
+		test.Errorf("comment changed parsing. got=%q", program.Statements[1].String())
+	}
+}
+
+func TestArrayLiteral(test *testing.T) {
+	p := New(lexer.New("[1, 2 * 2, fun(x) { x }, []]"))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	array, ok := stmt.Expression.(*ast.ArrayLiteral)
+	if !ok {
+		test.Fatalf("exp not *ast.ArrayLiteral. got=%T", stmt.Expression)
+	}
+
+	if len(array.Elements) != 4 {
+		test.Fatalf("len(array.Elements) not 4. got=%d", len(array.Elements))
+	}
+
+	testIntegerLiteral(test, array.Elements[0], 1)
+	testInfixExpression(test, array.Elements[1], 2, "*", 2)
+
+	if empty, ok := array.Elements[3].(*ast.ArrayLiteral); !ok || len(empty.Elements) != 0 {
+		test.Errorf("last element not an empty array. got=%s", array.Elements[3])
+	}
+
+	if array.Pos().Column != 1 || array.End().Column != 29 {
+		test.Errorf("wrong span. got=%s-%s", array.Pos(), array.End())
+	}
+}
+
+func TestIndexAndSliceExpressions(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"xs[1 + 1]", "(xs[(1 + 1)])"},
+		{"xs[-1]", "(xs[(-1)])"},
+		{"xs[1:3]", "(xs[1:3])"},
+		{"xs[:3]", "(xs[:3])"},
+		{"xs[1:]", "(xs[1:])"},
+		{"xs[:]", "(xs[:])"},
+		{"a * [1, 2, 3][b * c] * d", "((a * ([1, 2, 3][(b * c)])) * d)"},
+		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"fs[0](1)", "(fs[0])(1)"},
+		{"grid[1][2]", "((grid[1])[2])"},
+		{"-xs[0]", "(-(xs[0]))"},
+		{"xs[0] ^ 2", "((xs[0]) ^ 2)"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestArrayErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"[1, 2", "1:6: expected next token to be ], got EOF instead"},
+		{"xs[1", "1:5: expected next token to be ], got EOF instead"},
+		{"xs[1:2 3]", "1:8: expected next token to be ], got INT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestHashLiteral(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{}", "{}"},
+		{`{"one": 1, "two": 2, "three": 3}`, `{"one": 1, "two": 2, "three": 3}`},
+		{`{"a": 1 + 2, b: [1], 3: {true: fun(x) { x }}}`, `{"a": (1 + 2), b: [1], 3: {true: fun(x) x}}`},
+		{"{1: 2,}", "{1: 2}"},
+		{`{"k": 1}["k"]`, `({"k": 1}["k"])`},
+		{"if (x) { {1: 2} }", "ifx {1: 2}"},
+		{"h[1] = 2", "(h[1]) = 2"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New(`{"b": 1, "a": 2}`))
+	program := p.ParseProgram()
+	hash, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.HashLiteral)
+	if !ok {
+		test.Fatalf("exp not *ast.HashLiteral. got=%T", program.Statements[0])
+	}
+
+	if len(hash.Pairs) != 2 {
+		test.Fatalf("wrong number of pairs. got=%d", len(hash.Pairs))
+	}
+	if key, ok := hash.Pairs[0].Key.(*ast.StringLiteral); !ok || key.Value != "b" {
+		test.Errorf("first key not \"b\". got=%s", hash.Pairs[0].Key)
+	}
+	testIntegerLiteral(test, hash.Pairs[1].Value, 2)
+}
+
+func TestHashLiteralErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"{1 2}", "1:4: expected next token to be :, got INT instead"},
+		{"{1: 2", "1:6: expected next token to be }, got EOF instead"},
+		{"{1: 2 3: 4}", "1:7: expected next token to be }, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestModuleStatement(test *testing.T) {
+	input := `
+// Geometry helpers.
+module Geometry {
+	val pi = 3;
+	var area = fun(w, h) { w * h };
+}
+Geometry::area(2, 3);
+`
+	p := New(lexer.NewWithMode("", input, lexer.ScanComments))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if len(program.Statements) != 2 {
+		test.Fatalf("program has wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	module, ok := program.Statements[0].(*ast.ModuleStatement)
+	if !ok {
+		test.Fatalf("statement not *ast.ModuleStatement. got=%T", program.Statements[0])
+	}
+	if module.Name.Value != "Geometry" {
+		test.Errorf("module name not Geometry. got=%q", module.Name.Value)
+	}
+	if len(module.Body.Statements) != 2 {
+		test.Errorf("module body has wrong number of statements. got=%d", len(module.Body.Statements))
+	}
+	if module.Doc == nil || module.Doc.Text() != "Geometry helpers." {
+		test.Errorf("module doc not attached. got=%v", module.Doc)
+	}
+
+	call, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if !ok {
+		test.Fatalf("exp not *ast.CallExpression. got=%T", program.Statements[1])
+	}
+	scoped, ok := call.Function.(*ast.ScopedExpression)
+	if !ok {
+		test.Fatalf("call.Function not *ast.ScopedExpression. got=%T", call.Function)
+	}
+	testIdentifier(test, scoped.Left, "Geometry")
+	testIdentifier(test, scoped.Member, "area")
+}
+
+func TestScopedExpressionPrecedence(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"A::b", "A::b"},
+		{"A::B::c", "A::B::c"},
+		{"A::f(1) + 2", "(A::f(1) + 2)"},
+		{"-A::b", "(-A::b)"},
+		{"A::xs[0]", "(A::xs[0])"},
+		{"module M { val x = 1; }", "module M {val x = 1;}"},
+		{"module M { }; M::x", "module M {}M::x"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestModuleErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"module { }", "1:8: expected next token to be IDENT, got { instead"},
+		{"module M val x = 1;", "1:10: expected next token to be {, got VALUE instead"},
+		{"A::1", "1:4: expected next token to be IDENT, got INT instead"},
+		{"module M {} M = 1", "1:13: cannot assign to val binding M"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestImportStatement(test *testing.T) {
+	tests := []struct {
+		input        string
+		expected     string
+		expectedName string
+	}{
+		{`import "geometry"`, `import "geometry"`, "geometry"},
+		{`import "lib/geometry.clint";`, `import "lib/geometry.clint"`, "geometry"},
+		{`import Geometry from "./geo.clint"`, `import Geometry from "./geo.clint"`, "Geometry"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			test.Fatalf("statement not *ast.ImportStatement. got=%T", program.Statements[0])
+		}
+		if stmt.Name() != tt.expectedName {
+			test.Errorf("%q: wrong name. expected=%q, got=%q", tt.input, tt.expectedName, stmt.Name())
+		}
+	}
+}
+
+func TestImportErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"import geometry", "1:16: expected next token to be FROM, got EOF instead"},
+		{"import G from geo", "1:15: expected next token to be STRING, got IDENT instead"},
+		{"import 1", "1:8: expected next token to be STRING, got INT instead"},
+		{`import "my-lib"`, `1:8: cannot import "my-lib" without a name: "my-lib" is not an identifier`},
+		{`import "geometry"; geometry = 1`, "1:20: cannot assign to val binding geometry"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestClassStatement(test *testing.T) {
+	input := `
+class Point {
+	fun init(x, y) {
+		self.x = x;
+		self.y = y;
+	}
+
+	fun norm() { self.x * self.x + self.y * self.y }
+}
+`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(test, p)
+
+	if len(program.Statements) != 1 {
+		test.Fatalf("program has wrong number of statements. got=%d", len(program.Statements))
+	}
+
+	class, ok := program.Statements[0].(*ast.ClassStatement)
+	if !ok {
+		test.Fatalf("statement not *ast.ClassStatement. got=%T", program.Statements[0])
+	}
+	if class.Name.Value != "Point" {
+		test.Errorf("class name not Point. got=%q", class.Name.Value)
+	}
+	if len(class.Methods) != 2 {
+		test.Fatalf("class has wrong number of methods. got=%d", len(class.Methods))
+	}
+
+	init := class.Methods[0]
+	if init.Name.Value != "init" || len(init.Function.Parameters) != 2 {
+		test.Errorf("wrong init method. got=%s", init)
+	}
+	testLiteralExpression(test, init.Function.Parameters[0], "x")
+	testLiteralExpression(test, init.Function.Parameters[1], "y")
+
+	expected := "class Point {fun init(x, y) self.x = xself.y = y fun norm() ((self.x * self.x) + (self.y * self.y))}"
+	if program.String() != expected {
+		test.Errorf("expected=%q, got=%q", expected, program.String())
+	}
+}
+
+func TestMemberExpression(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"p.x", "p.x"},
+		{"p.x.y", "p.x.y"},
+		{"p.norm() + 1", "(p.norm() + 1)"},
+		{"-p.x", "(-p.x)"},
+		{"p.xs[0]", "(p.xs[0])"},
+		{"ps[0].x", "(ps[0]).x"},
+		{"M::p.x", "M::p.x"},
+		{"p.x = 1 + 2", "p.x = (1 + 2)"},
+		{"val p = 1; p.x = 2", "val p = 1;p.x = 2"},
+		{"class E {}", "class E {}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestClassErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"class { }", "1:7: expected next token to be IDENT, got { instead"},
+		{"class P { var x = 1; }", "1:11: expected next token to be FUN, got VAR instead"},
+		{"class P { fun (x) { x } }", "1:15: expected next token to be IDENT, got ( instead"},
+		{"class P { fun f() { 1 }", "1:24: expected next token to be }, got EOF instead"},
+		{"p.(x)", "1:3: expected next token to be IDENT, got ( instead"},
+		{"class P {} P = 1", "1:12: cannot assign to val binding P"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestClassInheritance(test *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Circle < Shape {}", "class Circle < Shape {}"},
+		{"class Circle < Shapes::Shape {}", "class Circle < Shapes::Shape {}"},
+		{"class C < S { fun area() { super.area() * 2 } }", "class C < S {fun area() (super.area() * 2)}"},
+		{"class C < S { fun f() { super.f } }", "class C < S {fun f() super.f}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(test, p)
+
+		if program.String() != tt.expected {
+			test.Errorf("%q: expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("class Circle < Shape { fun area() { super.area() } }"))
+	program := p.ParseProgram()
+	class := program.Statements[0].(*ast.ClassStatement)
+	testIdentifier(test, class.Superclass, "Shape")
+
+	body := class.Methods[0].Function.Body.Statements[0].(*ast.ExpressionStatement)
+	call, ok := body.Expression.(*ast.CallExpression)
+	if !ok {
+		test.Fatalf("exp not *ast.CallExpression. got=%T", body.Expression)
+	}
+	super, ok := call.Function.(*ast.SuperExpression)
+	if !ok {
+		test.Fatalf("call.Function not *ast.SuperExpression. got=%T", call.Function)
+	}
+	testIdentifier(test, super.Method, "area")
+}
+
+func TestClassInheritanceErrors(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"class A < A {}", "1:11: class A cannot inherit from itself"},
+		{"class A < {}", "1:11: expected next token to be IDENT, got { instead"},
+		{"super", "1:6: expected next token to be ., got EOF instead"},
+		{"super.(x)", "1:7: expected next token to be IDENT, got ( instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+}
+
+func TestMissingSemicolon(test *testing.T) {
+	tests := []struct {
+		input              string
+		expectedError      string
+		expectedStatements int
+	}{
+		{"var x = 1 var y = 2", "1:11: expected next token to be ;, got VAR instead", 2},
+		{"val x = 1 x", "1:11: expected next token to be ;, got IDENT instead", 1},
+		{"1 2", "1:3: expected next token to be ;, got INT instead", 1},
+		{"return 1 2", "1:10: expected next token to be ;, got INT instead", 1},
+		{"let x be 1 x", "1:12: expected next token to be ;, got IDENT instead", 1},
+		{`import "a" x`, "1:12: expected next token to be ;, got IDENT instead", 1},
+		{"fun() { 1 2 }", "1:11: expected next token to be ;, got INT instead", 1},
+		{"var h = {1: 2} h", "1:16: expected next token to be ;, got IDENT instead", 1},
+		{"fun() { 1 }() 2", "1:15: expected next token to be ;, got INT instead", 1},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+		if len(program.Statements) != tt.expectedStatements {
+			test.Errorf("%q: wrong number of statements. expected=%d, got=%d", tt.input, tt.expectedStatements, len(program.Statements))
+		}
+	}
+
+	// The semicolon may be left out at the end of a block or the input,
+	// and after a statement ending in a block.
+	valid := []string{
+		"var x = 1",
+		"fun() { var x = 1 }",
+		"if (x) { 1 } else { 2 }",
+		"module M { val x = 1 }",
+		"fun(n) { if (n < 2) { return n } fib(n - 1) }",
+		"class A { fun f() { 1 } }\nclass B < A { }\nB().f()",
+		"module M { val x = 1 }\nM::x",
+		"var f = fun() { 1 }\nf()",
+	}
+	for _, input := range valid {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		checkParserErrors(test, p)
+	}
+}
+
+func TestLexerErrorsReportedOnce(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`"a${1}`, "1:6: unterminated string literal"},
+		{`f(1, "abc)`, "1:6: unterminated string literal"},
+		{"var x = 1 @ 2;", "1:11: illegal character '@'"},
+		{"[1, 0x]", "1:5: hexadecimal literal has no digits"},
+		{"f(0b2)", "1:5: invalid digit '2' in binary literal"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+		}
+	}
+
+	// The statement after the one in error is still checked.
+	p := New(lexer.New("var x = @; var y = 1 2"))
+	p.ParseProgram()
+	if len(p.Errors()) != 2 {
+		test.Errorf("wrong number of errors. got=%q", p.Errors())
+	}
+}
+
+func TestClassDuplicateDefinitions(test *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+		expectedLabel string
+	}{
+		{"class P { fun f() { 1 } fun f() { 2 } }", "1:29: method f is already defined in class P", "1:15"},
+		{"class P { fun init() { self.norm = 1 } fun norm() { 2 } }", "1:29: field norm would hide method norm of class P", "1:44"},
+		{"class P { fun norm() { fun() { self.norm = 1 } } }", "1:37: field norm would hide method norm of class P", "1:15"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expectedError {
+			test.Errorf("%q: wrong errors. expected=%q, got=%q", tt.input, tt.expectedError, errors)
+			continue
+		}
+
+		labels := p.Diagnostics()[0].Labels
+		if len(labels) != 1 || labels[0].Span.Start.String() != tt.expectedLabel {
+			test.Errorf("%q: wrong label. expected at %s, got=%+v", tt.input, tt.expectedLabel, labels)
+		}
+	}
+
+	// Fields of an outer class are not checked against an inner one.
+	valid := []string{
+		"class P { fun init() { self.x = 1 } fun x2() { self.x * 2 } }",
+		"class P { fun f() { class Q { fun g() { 1 } }; self.g = 1 } }",
+		"class A { }; fun() { class A < A { } }",
+	}
+	for _, input := range valid {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		checkParserErrors(test, p)
+	}
+}
@@ -2,7 +2,10 @@ package repl
 
 import (
 	"bufio"
+	"clint/diagnostic"
+	"clint/evaluator"
 	"clint/lexer"
+	"clint/object"
 	"clint/parser"
 	"fmt"
 	"io"
@@ -20,8 +23,9 @@ const PROMPT = ">> "
 
 // Start ...
 func Start(in io.Reader, out io.Writer) {
-	fmt.Println(CLINT)
+	io.WriteString(out, CLINT+"\n")
 	scanner := bufio.NewScanner(in)
+	env := object.NewEnvironment()
 
 	for {
 		fmt.Fprint(out, PROMPT)
@@ -37,18 +41,22 @@ func Start(in io.Reader, out io.Writer) {
 
 		program := p.ParseProgram()
 
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		if len(p.Diagnostics()) != 0 {
+			printDiagnostics(out, line, p.Diagnostics())
 			continue
 		}
 
-		io.WriteString(out, program.String())
-		io.WriteString(out, "\n")
+		evaluated := evaluator.Eval(program, env)
+		if evaluated != nil {
+			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, "\n")
+		}
 	}
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	for _, error := range errors {
-		io.WriteString(out, "\t"+error+"\n")
+func printDiagnostics(out io.Writer, source string, diagnostics []diagnostic.Diagnostic) {
+	for _, d := range diagnostics {
+		diagnostic.Render(out, source, d)
+		io.WriteString(out, "\n")
 	}
 }
@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 // TokenType ...
 type TokenType string
 
@@ -7,6 +9,34 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position // position of the first character
+	End     Position // position just past the last character
+}
+
+// Position is a location in the source. Line and Column are 1-based,
+// Offset is the 0-based byte offset. The zero Position is not valid.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+// IsValid ...
+func (pos Position) IsValid() bool { return pos.Line > 0 }
+
+// String returns file:line:column, line:column when there is no file name,
+// or "-" for an invalid position.
+func (pos Position) String() string {
+	if !pos.IsValid() {
+		return "-"
+	}
+
+	s := fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+	if pos.Filename != "" {
+		s = pos.Filename + ":" + s
+	}
+	return s
 }
 
 const (
@@ -16,6 +46,10 @@ const (
 	INT         = "INT"
 	FLOAT       = "FLOAT"
 	STR         = "STRING"
+	TMPLHEAD    = "TMPLHEAD"   // "text${
+	TMPLMIDDLE  = "TMPLMIDDLE" // }text${
+	TMPLTAIL    = "TMPLTAIL"   // }text"
+	COMMENT     = "COMMENT"    // only emitted in lexer.ScanComments mode
 	ASSIGN      = "="
 	PLUS        = "+"
 	MINUS       = "-"
@@ -30,6 +64,7 @@ const (
 	COMMA       = ","
 	COLON       = ":"
 	MODACCESSOR = "::"
+	DOT         = "."
 	SEMI        = ";"
 	TELL        = "!"
 	ASK         = "?"
@@ -37,8 +72,13 @@ const (
 	RPAREN      = ")"
 	LBRACE      = "{"
 	RBRACE      = "}"
+	LBRACKET    = "["
+	RBRACKET    = "]"
 	MODULE      = "MODULE"
+	IMPORT      = "IMPORT"
+	FROM        = "FROM"
 	CLASS       = "CLASS"
+	SUPER       = "SUPER"
 	FUN         = "FUN"
 	LET         = "LET"
 	BE          = "BE"
@@ -58,7 +98,10 @@ const (
 
 var keywords = map[string]TokenType{
 	"module": MODULE,
+	"import": IMPORT,
+	"from":   FROM,
 	"class":  CLASS,
+	"super":  SUPER,
 	"fun":    FUN,
 	"var":    VAR,
 	"val":    VALUE,